@@ -1,12 +1,13 @@
 package base50
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
-	"strings"
+	"io"
+	"math/bits"
 )
 
-const configIndexAlphabet = false
-
 // Alphabet is the 50 output characters used when displaying base50
 //
 // We need 50 characters, 26*2 + 10 = 62. So we can drop 12.
@@ -29,37 +30,200 @@ const Alphabet = "0123456789" +
 	"ab" + "defgh" + "jk" + "mn" + "pqrstu" + "wxyz"
 
 const configOpt = true
-const panicDebug = true
+
+// The decodeMap entries for bytes that aren't in the alphabet, so decoding
+// only needs a single lookup for each character. Alphabet characters are
+// their value, which is always less than stopClass.
+const (
+	stopClass   = 0xFD // The stop character '.'
+	skipClass   = 0xFE // skipChar()s, which are ignored
+	invalidChar = 0xFF // Everything else
+)
+
+// An Encoding is a base50 encoding/decoding scheme, defined by a
+// 50-character alphabet. The most common encoding is StdEncoding, which uses
+// Alphabet.
+type Encoding struct {
+	encode    [50]byte
+	decodeMap [256]byte
+	subst     [256]byte // Lenient() substitutions, 0 for none
+	check     bool      // WithCheck() messages end with a check character
+
+	groupCheck bool // WithGroupCheck() groups end with a check character
+	strict     bool // Strict() decoding only allows what Encode() outputs
+	fixed      bool // FixedLength() groups are never shortened
+	ordered    bool // Ordered() encodings sort like the data
+	dense      bool // Dense() groups are 31 bytes in 44 characters
+	stop       bool // WithStop() messages always end with a stop character
+
+	maxSize    int64 // WithMaxSize() decoded bytes, 0 for no limit
+	maxSkipped int64 // WithMaxSkipped() characters, 0 for no limit
+
+	wrap  int  // WithLineWrap() characters in a line, 0 for none, -1 default
+	sep   byte // WithSeparator() character
+	every int  // WithSeparator() characters between each sep, 0 for none
+
+	codec *Codec // NewCodec() groups instead of base50, for decoding a Codec
+}
+
+// groupLen returns the length of a full group of encoded characters.
+func (enc *Encoding) groupLen() int {
+	if enc.codec != nil {
+		return enc.codec.chars
+	}
+	n := 10
+	if enc.dense {
+		n = 44
+	}
+	if enc.groupCheck {
+		n++
+	}
+	return n
+}
+
+// groupBytes returns the number of bytes in a full group.
+func (enc *Encoding) groupBytes() int {
+	if enc.codec != nil {
+		return enc.codec.bytes
+	}
+	if enc.dense {
+		return 31
+	}
+	return 7
+}
+
+// AlphabetError values describe why an alphabet given to NewEncoding() can't
+// be used.
+type AlphabetError string
+
+func (e AlphabetError) Error() string {
+	return "base50: invalid alphabet: " + string(e)
+}
+
+// NewEncoding returns a new Encoding defined by the given alphabet, which must
+// be 50 unique printable ASCII characters. The alphabet can't contain the stop
+// character '.', the underbar or whitespace as those are used when decoding.
+// The alphabet is used in order, so the first character is the value 0.
+func NewEncoding(alphabet string) (*Encoding, error) {
+	if len(alphabet) != 50 {
+		return nil, AlphabetError(fmt.Sprintf("length is %d not 50",
+			len(alphabet)))
+	}
+
+	e := new(Encoding)
+	copy(e.encode[:], alphabet)
+	if err := alphabetMap(&e.decodeMap, alphabet); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// alphabetMap checks the characters in alphabet, and sets decodeMap to the
+// value of each one, stopClass and skipClass for those characters, and
+// invalidChar for everything else.
+func alphabetMap(decodeMap *[256]byte, alphabet string) error {
+	for i := range decodeMap {
+		switch {
+		case i == '.':
+			decodeMap[i] = stopClass
+		case skipChar(byte(i)):
+			decodeMap[i] = skipClass
+		default:
+			decodeMap[i] = invalidChar
+		}
+	}
+
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		switch {
+		case c <= ' ' || c > '~':
+			return AlphabetError(fmt.Sprintf("%#U isn't printable ASCII",
+				rune(c)))
+		case c == '.' || skipChar(c):
+			return AlphabetError(fmt.Sprintf("%#U is reserved", rune(c)))
+		case decodeMap[c] != invalidChar:
+			return AlphabetError(fmt.Sprintf("%#U is repeated", rune(c)))
+		}
+		decodeMap[c] = byte(i)
+	}
+
+	return nil
+}
+
+func mustNewEncoding(alphabet string) *Encoding {
+	e, err := NewEncoding(alphabet)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// StdEncoding is the standard base50 encoding, using Alphabet.
+var StdEncoding = mustNewEncoding(Alphabet)
+
+// FixedLength returns a copy of enc where the last group is never shortened,
+// so the length of the encoding is always EncodeLen() of the length of the
+// input and doesn't depend on the data. Eg. 0x00 is "00." and not "0.".
+// When decoding, shortened groups are invalid (ErrNonCanonical).
+func (enc *Encoding) FixedLength() *Encoding {
+	e := *enc
+	e.fixed = true
+	return &e
+}
+
+// InternalError values describe a broken invariant inside the encoder or
+// decoder, these should never happen.
+type InternalError string
+
+func (e InternalError) Error() string {
+	return "base50: internal error: " + string(e)
+}
+
+// pow50x5 is 50**5, the most base50 characters that fit in 32 bits. A group
+// is split into two halves of 5 characters, so encoding and decoding only
+// needs 32-bit division/multiplication which is much faster on 32-bit CPUs.
+const pow50x5 = 312500000
+
+// divmod50x5 returns num/50**5 and num%50**5 for num < 50**10, by multiplying
+// with ceil(2**92/50**5) instead of doing a 64-bit division.
+func divmod50x5(num uint64) (uint32, uint32) {
+	hi, _ := bits.Mul64(num, 15845632502852867519)
+	q := hi >> 28
+	return uint32(q), uint32(num - q*pow50x5)
+}
 
 // See the documentation on Encode(). Roughly 7 binary bytes fits into 10 ASCII
 // bytes in base49 onwards.
-func encodeInt64(dst []byte, num uint64, outb int) {
+func (enc *Encoding) encodeInt64(dst []byte, num uint64, outb int) error {
 	// 0xFFFFFFFFFFFFFF = 0xFFFF_FFFF_FFFF_FF
-	if panicDebug && num > 0xFFFFFFFFFFFFFF {
-		panic(num)
-	}
-	if panicDebug && outb < 1 {
-		panic(outb)
+	if num > 0xFFFFFFFFFFFFFF && !(enc.ordered && num <= orderedMax) {
+		return InternalError(fmt.Sprintf("encode num: %#x", num))
 	}
-	if panicDebug && outb > 10 {
-		panic(outb)
+	if outb < 1 || outb > 10 {
+		return InternalError(fmt.Sprintf("encode len: %d", outb))
 	}
 
 	//	fmt.Printf("JDBG: enc: %d %#x\n", outb, num)
 
+	hi, lo := divmod50x5(num)
 	for i := outb - 1; i >= 0; i-- {
-		dst[i] = Alphabet[num%50]
-		num /= 50
+		if i == outb-6 { // The first half, after the last 5 characters
+			hi, lo = 0, hi
+		}
+		dst[i] = enc.encode[lo%50]
+		lo /= 50
 	}
 
-	if panicDebug && num > 0 {
-		panic(num)
+	if hi > 0 || lo > 0 {
+		return InternalError(fmt.Sprintf("encode num: %#x left", num))
 	}
+	return nil
 }
 
 // See doc. on Encode(), we encode the (upto) 7 binary bytes into a uint64
 // then we'll turn that into 10 ASCII bytes.
-func encodeBytes(dst, src []byte, outb int, opt uint64) int {
+func (enc *Encoding) encodeBytes(dst, src []byte, outb int, opt uint64) (int, error) {
 	num := uint64(src[0]) // 1 or 2 byte output
 	if outb >= 3 {
 		num <<= 8
@@ -85,43 +249,87 @@ func encodeBytes(dst, src []byte, outb int, opt uint64) int {
 		num <<= 8
 		num += uint64(src[6])
 	}
-	if configOpt && opt > num {
+	if configOpt && !enc.fixed && opt > num {
 		outb--
 	}
-	encodeInt64(dst, num, outb)
 
-	return outb
+	return outb, enc.encodeInt64(dst, num, outb)
 }
 
 // For the last group of bytes (< 7) we can output less than 10 ASCII bytes
-func encodeBytesSuffix(dst, src []byte) int {
+func (enc *Encoding) encodeBytesSuffix(dst, src []byte) (int, error) {
+	if enc.ordered {
+		return 10, enc.encodeInt64(dst, orderedGroup(src), 10)
+	}
+	if enc.dense {
+		return enc.encodeDense(dst, src), nil
+	}
+
 	switch len(src) {
 	case 1:
 		// 50**1. Optimze, Eg. 0 = 0
-		return encodeBytes(dst, src, 2, 50)
+		return enc.encodeBytes(dst, src, 2, 50)
 	case 2:
-		encodeBytes(dst, src, 3, 0)
-		return 3
+		return enc.encodeBytes(dst, src, 3, 0)
 	case 3:
 		// 50**4=6250000
-		return encodeBytes(dst, src, 5, 6250000)
+		return enc.encodeBytes(dst, src, 5, 6250000)
 	case 4:
-		return encodeBytes(dst, src, 6, 0)
+		return enc.encodeBytes(dst, src, 6, 0)
 	case 5:
 		// 50**7=781250000000
-		return encodeBytes(dst, src, 8, 781250000000)
+		return enc.encodeBytes(dst, src, 8, 781250000000)
 	case 6:
-		return encodeBytes(dst, src, 9, 0)
+		return enc.encodeBytes(dst, src, 9, 0)
 	default:
 		break
 	}
 
-	return encodeBytes(dst, src, 10, 0)
+	return enc.encodeBytes(dst, src, 10, 0)
 }
 
 // EncodeLen for every 3.5 bytes of input we have 5 bytes output and
 // we might need an extra byte of "padding". Eg. 0x00 = "0." | 0xFF = "55."
 func EncodeLen(x int) int {
+	return StdEncoding.EncodeLen(x)
+}
+
+// EncodeLen returns the maximum length in bytes of the base50 encoding of an
+// input buffer of length x, see EncodeLen(). For FixedLength() encodings this
+// is the exact length.
+func (enc *Encoding) EncodeLen(x int) int {
+	if x <= 0 {
+		if enc.stop { // An empty message is a lone stop character
+			return 1
+		}
+		return 0
+	}
+
+	n := encodeLen(x)
+	switch {
+	case enc.ordered: // The last group is never shortened
+		n = (x + 6) / 7 * 10
+		if x%7 != 0 {
+			n++
+		}
+	case enc.dense:
+		n = denseEncodeLen(x)
+	}
+
+	gb := enc.groupBytes()
+	if enc.groupCheck { // Check character for each group
+		n += (x + gb - 1) / gb
+	}
+	if enc.check { // Check character, and the stop character is always there
+		n++
+	}
+	if (enc.check || enc.stop) && x%gb == 0 {
+		n++
+	}
+	return n + enc.formatExtra(n)
+}
+
+func encodeLen(x int) int {
 	rem := x % 7
 	whole := (x / 7) * 10
 	switch rem {
@@ -139,11 +347,6 @@ func EncodeLen(x int) int {
 		return whole + 8 + 1 // Could be -1
 	case 6:
 		return whole + 9 + 1
-
-	default:
-		if panicDebug {
-			panic(rem)
-		}
 	}
 
 	return whole // No padding
@@ -171,88 +374,164 @@ func EncodeLen(x int) int {
 // Encode encodes src into EncodedLen(len(src)) bytes of dst. As a convenience,
 // it returns the number of bytes written to dst, but this value is always
 // EncodedLen(len(src)). Encode implements base50 encoding
+//
+// Like encoding/base64, Encode panics if dst is too small. Use EncodeChecked()
+// to get an error instead.
 func Encode(dst, src []byte) []byte {
+	return StdEncoding.Encode(dst, src)
+}
+
+// Encode encodes src using the encoding enc, see Encode().
+func (enc *Encoding) Encode(dst, src []byte) []byte {
+	n, _ := enc.encodeTo(dst, src) // Only internal errors are possible
+	return dst[:n]
+}
+
+// EncodeChecked encodes src into dst, like Encode(), returning the number of
+// bytes written. If dst is smaller than EncodeLen(len(src)) then it returns
+// io.ErrShortBuffer, and it never panics.
+func EncodeChecked(dst, src []byte) (int, error) {
+	return StdEncoding.EncodeChecked(dst, src)
+}
+
+// EncodeChecked encodes src using the encoding enc, see EncodeChecked().
+func (enc *Encoding) EncodeChecked(dst, src []byte) (int, error) {
+	if len(dst) < enc.EncodeLen(len(src)) {
+		return 0, io.ErrShortBuffer
+	}
+
+	return enc.encodeTo(dst, src)
+}
+
+// encodeTo does the work for Encode(), returning the number of bytes written
+// to dst.
+func (enc *Encoding) encodeTo(dst, src []byte) (int, error) {
+	n, err := enc.encodeGroups(dst, src)
+	if err != nil {
+		return n, err
+	}
+	return enc.format(dst, enc.encodeEnd(dst, n), 0), nil
+}
+
+// encodeEnd adds the WithCheck() check character, or the WithStop() stop
+// character, after the n bytes of encoded groups in dst, returning the new
+// number of bytes. An empty WithStop() message is just the stop character.
+func (enc *Encoding) encodeEnd(dst []byte, n int) int {
+	if n == 0 {
+		if enc.stop {
+			dst[0] = '.'
+			n++
+		}
+		return n
+	}
+	if !enc.check {
+		if enc.stop && dst[n-1] != '.' {
+			dst[n] = '.'
+			n++
+		}
+		return n
+	}
+
+	if dst[n-1] == '.' {
+		n--
+	}
+	var l luhn
+	l.addChars(enc, dst[:n])
+	return enc.appendCheck(dst, n, &l)
+}
+
+// encodeGroups encodes the groups of src into dst, returning the number of
+// bytes written to dst. Any shortened last group has a stop character.
+func (enc *Encoding) encodeGroups(dst, src []byte) (int, error) {
+	if enc.codec != nil {
+		return enc.codec.encodeGroups(dst, src), nil
+	}
+
 	idx := 0
 
-	// Get 7 bytes at once, just to make life easier...
-	for len(src) >= 7 {
-		_ = encodeBytes(dst[idx:], src, 10, 0)
-		src = src[7:]
-		idx += 10
+	// Get a group of bytes at once, just to make life easier...
+	gb := enc.groupBytes()
+	for len(src) >= gb {
+		i, err := enc.encodeBytesSuffix(dst[idx:], src[:gb])
+		if err != nil {
+			return idx, err
+		}
+		src = src[gb:]
+		idx += i
+		if enc.groupCheck {
+			dst[idx] = enc.checkChar(dst[idx-i : idx])
+			idx++
+		}
 	}
 
 	if len(src) > 0 {
-		i := encodeBytesSuffix(dst[idx:], src)
+		i, err := enc.encodeBytesSuffix(dst[idx:], src)
+		if err != nil {
+			return idx, err
+		}
 		idx += i
+		if enc.groupCheck {
+			dst[idx] = enc.checkChar(dst[idx-i : idx])
+			idx++
+		}
 		dst[idx] = '.'
 		idx++
 	}
 
-	return dst[:idx]
+	return idx, nil
 }
 
 // EncodeToBytes returns the base50 encoding of src
 func EncodeToBytes(src []byte) []byte {
-	dst := make([]byte, EncodeLen(len(src)))
-	return Encode(dst, src)
+	return StdEncoding.EncodeToBytes(src)
 }
 
-// EncodeToString returns the base50 encoding of src as a string
-func EncodeToString(src []byte) string {
-	return string(EncodeToBytes(src))
+// EncodeToBytes returns the base50 encoding of src using the encoding enc
+func (enc *Encoding) EncodeToBytes(src []byte) []byte {
+	dst := make([]byte, enc.EncodeLen(len(src)))
+	return enc.Encode(dst, src)
 }
 
-// from50Char converts a base50 character into its value and a success flag.
-// in theory we could index the alphabet, but this should be faster...
-func from50Char(c byte) (uint64, bool) {
-	if configIndexAlphabet {
-		idx := strings.IndexByte(Alphabet, c)
-		if idx == -1 {
-			return uint64(c), false
-		}
-		return uint64(idx), true
+// grow returns dst with room for at least n more bytes past len(dst).
+func grow(dst []byte, n int) []byte {
+	if cap(dst)-len(dst) < n {
+		// This doesn't allocate for the make, and grows like append().
+		dst = append(dst, make([]byte, n)...)[:len(dst)]
 	}
+	return dst
+}
 
-	switch {
-	case '0' <= c && c <= '9':
-		return uint64(c - '0'), true
-	case 'A' == c:
-		return uint64(c-'A') + 10, true
-	case 'E' <= c && c <= 'H':
-		return uint64(c-'E') + 10 + 1, true
-	case 'J' <= c && c <= 'N':
-		return uint64(c-'J') + 10 + 1 + 4, true
-	case 'P' == c:
-		return uint64(c-'P') + 10 + 1 + 4 + 5, true
-	case 'R' <= c && c <= 'U':
-		return uint64(c-'R') + 10 + 1 + 4 + 5 + 1, true
-	case 'W' <= c && c <= 'Z':
-		return uint64(c-'W') + 10 + 1 + 4 + 5 + 1 + 4, true
-	case 'a' <= c && c <= 'b':
-		return uint64(c-'a') + 10 + 1 + 4 + 5 + 1 + 4 + 4, true
-	case 'd' <= c && c <= 'h':
-		return uint64(c-'d') + 10 + 1 + 4 + 5 + 1 + 4 + 4 + 2, true
-	case 'j' <= c && c <= 'k':
-		return uint64(c-'j') + 10 + 1 + 4 + 5 + 1 + 4 + 4 + 2 + 5, true
-	case 'm' <= c && c <= 'n':
-		return uint64(c-'m') + 10 + 1 + 4 + 5 + 1 + 4 + 4 + 2 + 5 + 2, true
-	case 'p' <= c && c <= 'u':
-		return uint64(c-'p') + 10 + 1 + 4 + 5 + 1 + 4 + 4 + 2 + 5 + 2 + 2, true
-	case 'w' <= c && c <= 'z':
-		return uint64(c-'w') + 10 + 1 + 4 + 5 + 1 + 4 + 4 + 2 + 5 + 2 + 2 + 6, true
-	}
-
-	return 0, false
+// AppendEncode appends the base50 encoding of src to dst and returns the
+// extended buffer. It only allocates when dst doesn't have enough capacity.
+func AppendEncode(dst, src []byte) []byte {
+	return StdEncoding.AppendEncode(dst, src)
+}
+
+// AppendEncode appends the base50 encoding of src to dst using the encoding
+// enc, see AppendEncode().
+func (enc *Encoding) AppendEncode(dst, src []byte) []byte {
+	dst = grow(dst, enc.EncodeLen(len(src)))
+	n := len(dst)
+	return dst[:n+len(enc.Encode(dst[n:cap(dst)], src))]
+}
+
+// EncodeToString returns the base50 encoding of src as a string
+func EncodeToString(src []byte) string {
+	return StdEncoding.EncodeToString(src)
+}
+
+// EncodeToString returns the base50 encoding of src as a string using the
+// encoding enc
+func (enc *Encoding) EncodeToString(src []byte) string {
+	return string(enc.EncodeToBytes(src))
 }
 
 // skipChar returns true for characters we should skip when decoding,
 // mostly whitespace
 func skipChar(c byte) bool {
 	switch c {
-	case '\t':
-	case '\n':
-	case '\r':
-	case ' ':
+	case '\t', '\n', '\r', ' ':
+		return true
 	case '_': // Allows 0xFFFF_FFFF type stuff.
 		return true
 	}
@@ -260,6 +539,20 @@ func skipChar(c byte) bool {
 	return false
 }
 
+// Errors which a DecodeError wraps, to be used with errors.Is().
+var (
+	// ErrInvalidChar is for bytes that aren't in the alphabet, and aren't
+	// skipped.
+	ErrInvalidChar = errors.New("base50: invalid character")
+	// ErrOverflow is for groups with a value greater than 0xFFFF_FFFF_FFFF_FF.
+	ErrOverflow = errors.New("base50: group overflow")
+	// ErrNonCanonical is for groups with a value too big for their length,
+	// Eg. 56 should be 056.
+	ErrNonCanonical = errors.New("base50: non-canonical group")
+	// ErrTruncatedGroup is for a stop character with no group before it.
+	ErrTruncatedGroup = errors.New("base50: truncated group")
+)
+
 // InvalidByteError values describe errors resulting from an invalid byte in a base50 string.
 type InvalidByteError byte
 
@@ -267,6 +560,11 @@ func (e InvalidByteError) Error() string {
 	return fmt.Sprintf("base50: invalid byte: %#U", rune(e))
 }
 
+// Is makes InvalidByteError match ErrInvalidChar.
+func (e InvalidByteError) Is(target error) bool {
+	return target == ErrInvalidChar
+}
+
 // InvalidTotalError values describe errors resulting from an invalid series of
 // bytes in a base50 string (the value is greater than 16**14).
 type InvalidTotalError uint64
@@ -280,11 +578,52 @@ func (e InvalidTotalError) Error() string {
 		num)
 }
 
+// Is makes InvalidTotalError match ErrOverflow or ErrNonCanonical.
+func (e InvalidTotalError) Is(target error) bool {
+	if uint64(e) > 0xFFFF_FFFF_FFFF_FF {
+		return target == ErrOverflow
+	}
+	return target == ErrNonCanonical
+}
+
+// DecodeError values describe where in the input decoding failed. Err is an
+// InvalidByteError, an InvalidTotalError, ErrTruncatedGroup, ErrCheck,
+// ErrStopChar, a *LimitError or io.ErrShortBuffer.
+type DecodeError struct {
+	Offset        int // Offset in the input of the bad byte, or group
+	Group         int // Number of the group in the input, from 0
+	DecodedOffset int // Number of bytes decoded before the group
+	Err           error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%v (offset %d, group %d)", e.Err, e.Offset, e.Group)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
 // DecodeLen for every 10 bytes of input we have 7 bytes output, apart from
-// the last group.
+// the last group. This is a maximum, see DecodedLenExact() for the exact
+// length.
 func DecodeLen(x int) int {
+	return StdEncoding.DecodeLen(x)
+}
+
+// DecodeLen returns the maximum length in bytes of the decoded data
+// corresponding to x bytes of base50-encoded data, see DecodeLen().
+func (enc *Encoding) DecodeLen(x int) int {
 	// return ((x+1) * 10) / 7
 
+	if x < 0 {
+		return 0
+	}
+	if enc.dense {
+		return denseDecodeLen(x)
+	}
+
 	rem := x % 10
 	whole := (x / 10) * 7
 	switch rem {
@@ -309,113 +648,565 @@ func DecodeLen(x int) int {
 		return whole + 5
 	case 9:
 		return whole + 6
+	}
 
-	default:
-		if panicDebug {
-			panic(rem)
+	return whole // No extra
+}
+
+// DecodedLenExact returns the number of bytes in the decoding of src, taking
+// into account skipped characters, stop characters and shortened groups.
+// Unlike DecodeLen() this looks at the input, and it returns an error if the
+// input is malformed, see Valid().
+func DecodedLenExact(src []byte) (int, error) {
+	return StdEncoding.DecodedLenExact(src)
+}
+
+// DecodedLenExact returns the number of bytes in the decoding of src using the
+// encoding enc, see DecodedLenExact().
+func (enc *Encoding) DecodedLenExact(src []byte) (int, error) {
+	g := groupDecoder{enc: enc, stop: true, discard: true}
+
+	count, err := g.decode(nil, src)
+	if err == nil {
+		var n int
+		n, err = g.end(nil)
+		count += n
+	}
+	if err == nil && g.failed != nil {
+		err = g.failed
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Valid returns the error Decode() would return for src, or nil if src is
+// well formed. Nothing is decoded, so it doesn't need a buffer and it doesn't
+// allocate for valid input.
+func Valid(src []byte) error {
+	return StdEncoding.Valid(src)
+}
+
+// Valid checks src using the encoding enc, see Valid().
+func (enc *Encoding) Valid(src []byte) error {
+	_, err := enc.DecodedLenExact(src)
+	return err
+}
+
+// decodeHalf decodes upto 5 base50 characters, which fit in 32 bits.
+func (enc *Encoding) decodeHalf(src []byte) (uint32, error) {
+	var num uint32
+	for _, c := range src {
+		v := enc.decodeMap[c]
+		if v >= stopClass {
+			return 0, InvalidByteError(c)
 		}
+		num = num*50 + uint32(v)
 	}
+	return num, nil
+}
 
-	return whole // No extra
+// decodeGroup decodes a single group of (upto) 10 base50 characters into dst,
+// returning the number of bytes written. See the table on Encode().
+func (enc *Encoding) decodeGroup(dst, grp []byte) (int, error) {
+	if enc.codec != nil {
+		return enc.codec.decodeGroup(dst, grp)
+	}
+	if enc.dense {
+		return enc.decodeDense(dst, grp)
+	}
+
+	// The first half of the characters, and the last 5, see encodeInt64().
+	split := len(grp) - 5
+	if split < 0 {
+		split = 0
+	}
+	hi, err := enc.decodeHalf(grp[:split])
+	if err != nil {
+		return 0, err
+	}
+	lo, err := enc.decodeHalf(grp[split:])
+	if err != nil {
+		return 0, err
+	}
+	return enc.decodeNum(dst, grp, uint64(hi)*pow50x5+uint64(lo))
 }
 
-// Decode decodes src into DecodedLen(len(src)) bytes, returning the actual
-// number of bytes written to dst.
-//
-// Decode expects that src contains only base50 characters, or whitespace/underbar
-// or the stop character if you've concatenated multiple encodings together.
-// Decode also expects that src has a correct encoding (Eg. 56 is not valid).
-// If the input is malformed, Decode returns the number of bytes decoded before
-// the error.
-func Decode(dst, src []byte) ([]byte, error) {
-	count := 0
-	odst := dst
+// foldGroup returns the value of a full group from its 10 base50 values.
+// Pairs of values are folded together first, so there are fewer dependent
+// multiplies than in decodeHalf().
+func foldGroup(v *[10]byte) uint64 {
+	a := uint32(v[0])*50 + uint32(v[1])
+	b := uint32(v[2])*50 + uint32(v[3])
+	c := uint32(v[5])*50 + uint32(v[6])
+	d := uint32(v[7])*50 + uint32(v[8])
+	hi := a*125000 + b*50 + uint32(v[4])
+	lo := c*125000 + d*50 + uint32(v[9])
+	return uint64(hi)*pow50x5 + uint64(lo)
+}
 
-	for len(src) > 0 {
-		var v [10]uint64
-		var ok [10]bool
+// decodeNum writes the bytes of num, the value of the base50 characters in
+// grp, into dst returning the number of bytes written.
+func (enc *Encoding) decodeNum(dst, grp []byte, num uint64) (int, error) {
+	if enc.ordered {
+		return decodeOrdered(dst, grp, num)
+	}
+	if num > 0xFFFFFFFFFFFFFF {
+		return 0, InvalidTotalError(num)
+	}
+	if enc.fixed {
+		switch len(grp) {
+		case 1, 4, 7: // Shortened, see encodeBytesSuffix()
+			return 0, InvalidTotalError(num)
+		}
+	} else if enc.strict && enc.decodeMap[grp[0]] == 0 {
+		switch len(grp) {
+		case 2, 5, 8: // Would have been shortened, see encodeBytesSuffix()
+			return 0, InvalidTotalError(num)
+		}
+	}
+	enum := num // Save the original num, for errors.
 
-		var Tbuf [10]byte
-		nsrc := Tbuf[:0]
-		for i := 0; len(src) > 0 && len(nsrc) < 10; {
-			c := src[0]
-			src = src[1:]
+	//		fmt.Printf("JDBG: dec: %d %#x\n", len(grp), num)
 
-			if skipChar(c) {
-				continue
+	count := enc.DecodeLen(len(grp))
+	if len(dst) < count {
+		return 0, io.ErrShortBuffer
+	}
+	for i := count - 1; i >= 0; i-- {
+		dst[i] = byte(num & 0xFF)
+		num >>= 8
+	}
+
+	if num > 0 {
+		return 0, InvalidTotalError(enum)
+	}
+
+	return count, nil
+}
+
+// groupDecoder holds the state for decoding base50 characters into groups, so
+// the input can be split. Used by Decode() and the streaming decoder.
+type groupDecoder struct {
+	enc   *Encoding
+	grp   [64]byte // characters of the current group, skipChar()s removed
+	ngrp  int      // number of characters in grp
+	start int      // offset in the input of the first character in grp
+	off   int      // offset in the input of the next call to decode()
+	group int      // number of groups decoded
+	count int      // number of bytes decoded
+	stop  bool     // at the start of input, or just after a stop character
+	empty bool     // had a stop character with nothing before it
+
+	// For WithCheck() encodings the last character is held back, as it might
+	// be the check character.
+	held     bool
+	heldC    byte
+	heldOff  int
+	luhn     luhn // check for the current message
+	msgCount int  // number of bytes decoded before the current message
+
+	failed GroupCheckError // WithGroupCheck() groups that didn't match
+
+	segments bool  // record the end of each message in ends
+	ends     []int // count at the end of each message
+	segEnd   int   // count at the end of the last message
+
+	discard bool     // decode groups into scratch, for Valid()
+	scratch [31]byte // a decoded group, when discarding
+
+	done  bool // Strict() decoding has had the stop character
+	short bool // the last group decoded was less than 7 bytes
+
+	skipped int64 // number of skipChar()s, for WithMaxSkipped()
+
+	// For Strict() decoding of WithLineWrap() and WithSeparator() encodings.
+	pos    int64 // number of characters, without the stop character
+	sepC   byte  // the newline or separator before the next character
+	sepOff int   // offset in the input of sepC
+}
+
+func (g *groupDecoder) error(off int, err error) error {
+	return &DecodeError{Offset: off, Group: g.group, DecodedOffset: g.count,
+		Err: err}
+}
+
+// decode reads the base50 characters from src, decoding each complete group
+// into dst and returning the number of bytes written. The last incomplete
+// group is kept for the next call, or end().
+func (g *groupDecoder) decode(dst, src []byte) (int, error) {
+	n := 0
+	out := dst
+
+	for i := 0; i < len(src); i++ {
+		if g.ngrp == 0 {
+			used, num, err := g.decodeRun(out, src[i:], g.off+i)
+			n += num
+			if err != nil {
+				return n, err
+			}
+			if !g.discard {
+				out = dst[n:]
 			}
-			if c == '.' {
+			if i += used; i == len(src) {
 				break
 			}
+		}
 
-			nsrc = append(nsrc, c)
+		var num int
+		var err error
 
-			if v[i], ok[i] = from50Char(c); !ok[i] {
-				return odst[:count], InvalidByteError(c)
+		c := src[i]
+		v := g.enc.decodeMap[c]
+		if g.enc.strict && !g.done && v < skipClass {
+			if err := g.strictFormat(c, g.off+i); err != nil {
+				return n, err
 			}
-
-			i++
 		}
+		switch {
+		case g.done:
+			return n, g.error(g.off+i, ErrStopChar)
+
+		case v == skipClass:
+			if g.enc.strict {
+				if err := g.strictSkip(c, g.off+i); err != nil {
+					return n, err
+				}
+			}
+			if err := g.limitSkipped(g.off + i); err != nil {
+				return n, err
+			}
+			continue
+
+		case v == stopClass:
+			num, err = g.stopChar(out, g.off+i)
 
-		var num uint64
+		case v == invalidChar:
+			return n, g.error(g.off+i, InvalidByteError(c))
 
-		for i := 0; i < len(nsrc) && i < 10; i++ {
-			num *= 50
-			num += v[i]
+		case g.enc.check:
+			held, heldC, heldOff := g.held, g.heldC, g.heldOff
+			g.held, g.heldC, g.heldOff = true, c, g.off+i
+			if !held {
+				continue
+			}
+			num, err = g.add(out, heldC, heldOff)
+
+		default:
+			num, err = g.add(out, c, g.off+i)
+		}
+
+		n += num
+		if err != nil {
+			return n, err
 		}
-		if num > 0xFFFFFFFFFFFFFF {
-			return odst[:count], InvalidTotalError(num)
+		if !g.discard {
+			out = dst[n:]
 		}
-		enum := num // Save the original num, for errors.
+	}
+	g.off += len(src)
+
+	return n, nil
+}
+
+// swarClean returns true if none of the 8 bytes in x are less than '0', an
+// underbar or non-ASCII, which covers the stop character and all of the
+// skipChar()s. This checks all 8 bytes at once (SIMD within a register).
+func swarClean(x uint64) bool {
+	const ones = 0x0101010101010101
+	const highs = 0x8080808080808080
 
-		//		fmt.Printf("JDBG: dec: %d %#x\n", len(nsrc), num)
+	below := (x - '0'*ones) &^ x // High bit set for bytes < '0'
+	u := x ^ '_'*ones
+	under := (u - ones) &^ u // High bit set for bytes == '_'
+	return (below|under|x)&highs == 0
+}
 
-		// See the table on Encode()
-		if len(nsrc) >= 10 {
-			dst[6] = byte(num & 0xFF)
-			num >>= 8
-			count++
+// decodeRun is the fast path for decode(), for a run of full groups at the
+// start of src without any skipChar()s, stop characters or errors. They are
+// decoded into dst without looking at each character in decode(), returning
+// the number of characters used and bytes written. Anything else is left for
+// decode(), so this can stop at any group. An error from decoding a group is
+// returned, as dst might already have been written over src.
+//
+// For 10 character base50 groups the values looked up to check the group are
+// folded straight into the value of the group, see foldGroup().
+func (g *groupDecoder) decodeRun(dst, src []byte, off int) (int, int, error) {
+	if g.done || g.short || g.enc.check || (g.enc.strict && g.enc.formatted()) {
+		return 0, 0, nil
+	}
+
+	glen := g.enc.groupLen()
+	fold := glen == 10 && g.enc.codec == nil
+	used, n := 0, 0
+	for len(src)-used >= glen {
+		grp := src[used : used+glen]
+		i := 0
+		for ; i+8 <= len(grp); i += 8 {
+			if !swarClean(binary.LittleEndian.Uint64(grp[i:])) {
+				return used, n, nil
+			}
 		}
-		if len(nsrc) >= 9 {
-			dst[5] = byte(num & 0xFF)
-			num >>= 8
-			count++
+		var all byte // Alphabet values are < 0x80, everything else isn't
+		var vals [10]byte
+		if fold {
+			for i, c := range grp[:10] {
+				vals[i] = g.enc.decodeMap[c]
+				all |= vals[i]
+			}
+		} else {
+			for _, c := range grp {
+				all |= g.enc.decodeMap[c]
+			}
 		}
-		if len(nsrc) >= 7 {
-			dst[4] = byte(num & 0xFF)
-			num >>= 8
-			count++
+		if all >= 0x80 {
+			return used, n, nil
 		}
-		if len(nsrc) >= 6 {
-			dst[3] = byte(num & 0xFF)
-			num >>= 8
-			count++
+
+		out := g.scratch[:]
+		if !g.discard {
+			out = dst[n:]
 		}
-		if len(nsrc) >= 4 {
-			dst[2] = byte(num & 0xFF)
-			num >>= 8
-			count++
+		g.start = off + used
+		var num int
+		var err error
+		if fold {
+			num, err = g.flushed(g.enc.decodeNum(out, grp, foldGroup(&vals)))
+		} else {
+			g.ngrp = copy(g.grp[:], grp)
+			num, err = g.flush(out)
 		}
-		if len(nsrc) >= 3 {
-			dst[1] = byte(num & 0xFF)
-			num >>= 8
-			count++
+		if err != nil {
+			return used, n, err
 		}
-		dst[0] = byte(num & 0xFF)
-		num >>= 8
-		count++
+		g.stop = false
+		used += glen
+		n += num
+	}
+
+	return used, n, nil
+}
+
+// add adds the character c, from offset off in the input, to the current
+// group. If that completes the group it's decoded into dst, returning the
+// number of bytes written.
+func (g *groupDecoder) add(dst []byte, c byte, off int) (int, error) {
+	if g.short && g.enc.strict { // Ordered() groups can be short
+		return 0, g.error(off, ErrStopChar)
+	}
+	if g.ngrp == 0 {
+		g.start = off
+	}
+	g.grp[g.ngrp] = c
+	g.ngrp++
+	if g.enc.check {
+		g.luhn.add(g.enc.decodeMap[c])
+	}
+	if g.ngrp < g.enc.groupLen() {
+		return 0, nil
+	}
+
+	g.stop = false
+	return g.flush(dst)
+}
 
-		if panicDebug && len(nsrc) >= 10 && num > 0 {
-			panic(num)
+// stopChar handles a stop character at offset off in the input, decoding the
+// current group into dst and returning the number of bytes written.
+func (g *groupDecoder) stopChar(dst []byte, off int) (int, error) {
+	if g.enc.strict {
+		// Only shortened groups, and check characters, have a stop
+		// character after them (or everything for WithStop(), including
+		// an empty message).
+		if g.ngrp == 0 && !g.short && !g.enc.check &&
+			!(g.enc.stop && (!g.stop || g.group == 0)) {
+			return 0, g.error(off, ErrStopChar)
 		}
-		if num > 0 {
-			return odst[:count], InvalidTotalError(enum)
+		g.done = true
+	}
+
+	if g.enc.check {
+		if !g.held {
+			return 0, g.emptyMessage(off)
+		}
+		return g.endMessage(dst)
+	}
+
+	if g.ngrp == 0 {
+		// A stop character just after a full group is fine, but
+		// "1x.." or a leading "." are not, unless they are empty messages.
+		if g.stop {
+			return 0, g.emptyMessage(off)
+		}
+		g.stop = true
+		g.endSegment()
+		return 0, nil
+	}
+
+	g.stop = true
+	n, err := g.flush(dst)
+	if err == nil {
+		g.endSegment()
+	}
+	return n, err
+}
+
+// emptyMessage handles a stop character at offset off with nothing before it
+// in the message. This is an empty segment when decoding segments, and a
+// single empty WithStop() message is fine, but anything else is an error.
+func (g *groupDecoder) emptyMessage(off int) error {
+	if !g.segments && (!g.enc.stop || g.group > 0 || g.empty) {
+		return g.error(off, ErrTruncatedGroup)
+	}
+	g.empty = true
+	g.endSegment()
+	return nil
+}
+
+// endMessage checks the held back check character against the current
+// message, and then decodes the current group into dst returning the number
+// of bytes written.
+func (g *groupDecoder) endMessage(dst []byte) (int, error) {
+	g.held = false
+	if g.enc.decodeMap[g.heldC] != g.luhn.check() {
+		return 0, &DecodeError{Offset: g.heldOff, Group: g.group,
+			DecodedOffset: g.msgCount, Err: ErrCheck}
+	}
+
+	n, err := g.flush(dst)
+	g.luhn = luhn{}
+	g.msgCount = g.count
+	g.stop = true
+	if err == nil {
+		g.endSegment()
+	}
+	return n, err
+}
+
+// endSegment records the end of a message, when segments is set.
+func (g *groupDecoder) endSegment() {
+	if g.segments {
+		g.ends = append(g.ends, g.count)
+		g.segEnd = g.count
+	}
+}
+
+// flush decodes whatever is in grp as a complete group into dst, returning
+// the number of bytes written.
+func (g *groupDecoder) flush(dst []byte) (int, error) {
+	if g.ngrp == 0 { // Only whitespace
+		return 0, nil
+	}
+	if g.discard {
+		dst = g.scratch[:]
+	}
+	if g.enc.groupCheck {
+		return g.flushGroupCheck(dst)
+	}
+
+	return g.flushed(g.enc.decodeGroup(dst, g.grp[:g.ngrp]))
+}
+
+// flushed finishes decoding the current group, after it has been decoded into
+// n bytes or the error err, returning the number of bytes written.
+func (g *groupDecoder) flushed(n int, err error) (int, error) {
+	if err != nil {
+		return 0, g.error(g.start, err)
+	}
+	if err := g.limitSize(g.start, n); err != nil {
+		return 0, err
+	}
+	g.ngrp = 0
+	g.group++
+	g.count += n
+	g.short = n < g.enc.groupBytes()
+
+	return n, nil
+}
+
+// end decodes whatever is left at the end of the input into dst, returning
+// the number of bytes written.
+func (g *groupDecoder) end(dst []byte) (int, error) {
+	if g.enc.strict && g.sepC != 0 {
+		return 0, g.error(g.sepOff, InvalidByteError(g.sepC))
+	}
+	if g.enc.strict && !g.done && (g.ngrp > 0 || g.held || g.short ||
+		g.enc.stop) {
+		return 0, g.error(g.off, ErrStopChar)
+	}
+	if g.enc.check {
+		if !g.held {
+			return 0, nil
 		}
+		return g.endMessage(dst)
+	}
+
+	n, err := g.flush(dst)
+	if err == nil && g.count > g.segEnd {
+		g.endSegment()
+	}
+	return n, err
+}
+
+// verified returns how many of the last n bytes written are from messages
+// which have been checked, or n if the encoding doesn't have checks.
+func (g *groupDecoder) verified(n int) int {
+	if !g.enc.check {
+		return n
+	}
+	if u := g.count - g.msgCount; u < n {
+		return n - u
+	}
+	return 0
+}
+
+// Decode decodes src into DecodedLen(len(src)) bytes, returning the actual
+// number of bytes written to dst.
+//
+// Decode expects that src contains only base50 characters, or whitespace/underbar
+// or the stop character if you've concatenated multiple encodings together.
+// Decode also expects that src has a correct encoding (Eg. 56 is not valid).
+// If the input is malformed, Decode returns the number of bytes decoded before
+// the error, and a *DecodeError saying where the error is.
+func Decode(dst, src []byte) ([]byte, error) {
+	return StdEncoding.Decode(dst, src)
+}
 
-		dst = odst[count:]
+// Decode decodes src using the encoding enc, see Decode().
+func (enc *Encoding) Decode(dst, src []byte) ([]byte, error) {
+	g := groupDecoder{enc: enc, stop: true}
+
+	count, err := g.decode(dst, src)
+	if err == nil {
+		var n int
+		n, err = g.end(dst[count:])
+		count += n
+	}
+	if err == nil && g.failed != nil {
+		err = g.failed
+	}
+	if err != nil {
+		count = g.verified(count)
 	}
 
-	return odst[:count], nil
+	return dst[:count], err
+}
+
+// AppendDecode appends the base50 decoding of src to dst and returns the
+// extended buffer. It only allocates when dst doesn't have enough capacity.
+// If the input is malformed, it returns dst extended by the bytes decoded
+// before the error, and the error.
+func AppendDecode(dst, src []byte) ([]byte, error) {
+	return StdEncoding.AppendDecode(dst, src)
+}
+
+// AppendDecode appends the base50 decoding of src to dst using the encoding
+// enc, see AppendDecode().
+func (enc *Encoding) AppendDecode(dst, src []byte) ([]byte, error) {
+	dst = grow(dst, enc.DecodeLen(len(src)))
+	n := len(dst)
+	decoded, err := enc.Decode(dst[n:cap(dst)], src)
+	return dst[:n+len(decoded)], err
 }
 
 // DecodeString returns the bytes represented by the base50 string s.
@@ -426,8 +1217,14 @@ func Decode(dst, src []byte) ([]byte, error) {
 // If the input is malformed, DecodeString returns the number of bytes decoded before
 // the error.
 func DecodeString(s string) ([]byte, error) {
+	return StdEncoding.DecodeString(s)
+}
+
+// DecodeString returns the bytes represented by the base50 string s using the
+// encoding enc, see DecodeString().
+func (enc *Encoding) DecodeString(s string) ([]byte, error) {
 	src := []byte(s)
 	// We can use the source slice itself as the destination
 	// because we read the "number" first and then write. And src always >.
-	return Decode(src, src)
+	return enc.Decode(src, src)
 }
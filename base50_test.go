@@ -2,8 +2,16 @@ package base50
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestBase50AllOneByte(t *testing.T) {
@@ -522,3 +530,611 @@ func TestBase50DecEdgecases(t *testing.T) {
 
 	testDataRev(t, data)
 }
+
+func TestBase50DecSkip(t *testing.T) {
+	data := []struct {
+		val string
+		tst string
+	}{
+		{"", ""},
+		{" \t\r\n", ""},
+		{"1x.", "a"},
+		{" 1 x . ", "a"},
+		{"H1jP5_eefyh\n", "abcdefg"},
+		{"H1jP5\r\neefyh\n112sa.\n", "abcdefgabc"},
+	}
+
+	for i := range data {
+		decoded, err := DecodeString(data[i].val)
+		if err != nil {
+			t.Errorf("bad err: %d: %v made %v\n",
+				i, data[i].val, err)
+		}
+		if string(decoded) != data[i].tst {
+			t.Errorf("data not equal: %v: %q\n tst=<%s>\n got <%s>\n",
+				i, data[i].val, data[i].tst, decoded)
+		}
+	}
+}
+
+func TestBase50NewEncoding(t *testing.T) {
+	bad := []string{
+		"",
+		Alphabet[1:],
+		Alphabet + "v",
+		"1" + Alphabet[1:],
+		"." + Alphabet[1:],
+		"_" + Alphabet[1:],
+		" " + Alphabet[1:],
+		"\x80" + Alphabet[1:],
+	}
+	for i := range bad {
+		if _, err := NewEncoding(bad[i]); err == nil {
+			t.Errorf("no err: %d: %q\n", i, bad[i])
+		}
+	}
+
+	enc, err := NewEncoding(Alphabet)
+	if err != nil {
+		t.Fatalf("bad err: %v\n", err)
+	}
+	if *enc != *StdEncoding {
+		t.Errorf("Alphabet encoding not equal to StdEncoding\n")
+	}
+
+	// Same as the Alphabet, but with the upper case letters swapped for the
+	// visually confusing ones.
+	alt := "0123456789" + "BCDIOQVcilov" + Alphabet[22:]
+	enc, err = NewEncoding(alt)
+	if err != nil {
+		t.Fatalf("bad err: %v\n", err)
+	}
+
+	data := []struct {
+		val []byte
+		std string
+		alt string
+	}{
+		{[]byte{}, "", ""},
+		{[]byte{'a'}, "1x.", "1x."},
+		{[]byte("abcdefg"), "H1jP5eefyh", "O1jo5eefyh"},
+		{[]byte{0xFF, 0xFF, 0xFF}, "2gAtJ.", "2gBtQ."},
+		{[]byte{1, 0, 0, 0, 0, 0, 0}, "07AHNwGgG6", "07BOlwIgI6"},
+	}
+	for i := range data {
+		encoded := enc.EncodeToString(data[i].val)
+		if encoded != data[i].alt {
+			t.Errorf("data not equal: %d: %v\n tst=<%s>\n got <%s>\n",
+				i, data[i].val, data[i].alt, encoded)
+		}
+		if EncodeToString(data[i].val) != data[i].std {
+			t.Errorf("data not equal: %d: %v\n tst=<%s>\n got <%s>\n",
+				i, data[i].val, data[i].std, EncodeToString(data[i].val))
+		}
+
+		decoded, err := enc.DecodeString(encoded)
+		if err != nil {
+			t.Errorf("bad err: %d: %v made %v\n", i, encoded, err)
+		}
+		if !bytes.Equal(decoded, data[i].val) {
+			t.Errorf("decoded not equal: %d: %v\n got <%x>\n",
+				i, data[i].val, decoded)
+		}
+	}
+
+	if _, err := enc.DecodeString("2gAtJ."); err == nil {
+		t.Errorf("no err: decoding std alphabet with alt alphabet\n")
+	}
+}
+
+func TestBase50DecErrors(t *testing.T) {
+	data := []struct {
+		val   string
+		tst   string
+		err   error
+		off   int
+		group int
+		doff  int
+	}{
+		{"!", "", ErrInvalidChar, 0, 0, 0},
+		{"1x.1O.", "a", ErrInvalidChar, 4, 1, 1},
+		{"H1jP5 eefyh 112 sI.", "abcdefg", ErrInvalidChar, 17, 1, 7},
+		{"56.", "", ErrNonCanonical, 0, 0, 0},
+		{"1x. 56.", "a", ErrNonCanonical, 4, 1, 1},
+		{"zzzzzzzzzz", "", ErrOverflow, 0, 0, 0},
+		{"H1jP5eefyh\nzzzzzzzzzz", "abcdefg", ErrOverflow, 11, 1, 7},
+		{".", "", ErrTruncatedGroup, 0, 0, 0},
+		{"1x..", "a", ErrTruncatedGroup, 3, 1, 1},
+		{"1x. .", "a", ErrTruncatedGroup, 4, 1, 1},
+	}
+
+	for i := range data {
+		decoded, err := DecodeString(data[i].val)
+		if string(decoded) != data[i].tst {
+			t.Errorf("data not equal: %v: %q\n tst=<%s>\n got <%s>\n",
+				i, data[i].val, data[i].tst, decoded)
+		}
+		if !errors.Is(err, data[i].err) {
+			t.Errorf("bad err: %d: %q made %v\n", i, data[i].val, err)
+			continue
+		}
+
+		var derr *DecodeError
+		if !errors.As(err, &derr) {
+			t.Errorf("bad err: %d: %q made %T\n", i, data[i].val, err)
+			continue
+		}
+		if derr.Offset != data[i].off || derr.Group != data[i].group ||
+			derr.DecodedOffset != data[i].doff {
+			t.Errorf("bad err: %d: %q made %d/%d/%d\n", i, data[i].val,
+				derr.Offset, derr.Group, derr.DecodedOffset)
+		}
+
+		// The streaming decoder should have the same errors.
+		r := iotest.OneByteReader(strings.NewReader(data[i].val))
+		decoded, serr := ioutil.ReadAll(NewDecoder(r))
+		if string(decoded) != data[i].tst {
+			t.Errorf("data not equal: %v: %q\n tst=<%s>\n got <%s>\n",
+				i, data[i].val, data[i].tst, decoded)
+		}
+		if serr == nil || serr.Error() != err.Error() {
+			t.Errorf("bad stream err: %d: %q made %v\n", i, data[i].val, serr)
+		}
+	}
+
+	// Old style errors still work.
+	var berr InvalidByteError
+	if _, err := DecodeString("1O"); !errors.As(err, &berr) || berr != 'O' {
+		t.Errorf("bad err: %v\n", err)
+	}
+	var terr InvalidTotalError
+	if _, err := DecodeString("56"); !errors.As(err, &terr) || terr != 256 {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
+
+func TestBase50ShortBuffer(t *testing.T) {
+	val := []byte("abcdefghij")
+	for l := 0; l < EncodeLen(len(val)); l++ {
+		dst := make([]byte, l)
+		if _, err := EncodeChecked(dst, val); err != io.ErrShortBuffer {
+			t.Errorf("bad err: len=%d made %v\n", l, err)
+		}
+	}
+	dst := make([]byte, EncodeLen(len(val)))
+	n, err := EncodeChecked(dst, val)
+	if err != nil {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if string(dst[:n]) != "H1jP5eefyh14k4b." {
+		t.Errorf("data not equal: <%s>\n", dst[:n])
+	}
+
+	encoded := dst[:n]
+	for l := 0; l < len(val); l++ {
+		dst := make([]byte, l)
+		decoded, err := Decode(dst, encoded)
+		if !errors.Is(err, io.ErrShortBuffer) {
+			t.Errorf("bad err: len=%d made %v\n", l, err)
+		}
+		tst := val[:(l/7)*7]
+		if !bytes.Equal(decoded, tst) {
+			t.Errorf("decoded not equal: len=%d: %v\n got <%s>\n",
+				l, tst, decoded)
+		}
+	}
+
+	if EncodeLen(-1) != 0 || DecodeLen(-1) != 0 {
+		t.Errorf("bad len: %d %d\n", EncodeLen(-1), DecodeLen(-1))
+	}
+}
+
+func TestBase50DecNoPanic(t *testing.T) {
+	chars := []byte(Alphabet + "._ \n!O")
+	rnd := rand.New(rand.NewSource(50))
+	for i := 0; i < 10000; i++ {
+		src := make([]byte, rnd.Intn(40))
+		for j := range src {
+			src[j] = chars[rnd.Intn(len(chars))]
+		}
+
+		// Any size of dst is fine, it just errors when it's too small.
+		dst := make([]byte, rnd.Intn(DecodeLen(len(src))+1))
+		decoded, err := Decode(dst, src)
+		if err == nil && len(decoded) > len(dst) {
+			t.Errorf("bad len: %q made %d\n", src, len(decoded))
+		}
+	}
+}
+
+func TestBase50Append(t *testing.T) {
+	vals := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte("abcdefg"),
+		[]byte("abcdefghij"),
+	}
+	prefixes := [][]byte{
+		nil,
+		[]byte("x"),
+		make([]byte, 3, 100),
+	}
+
+	for i, val := range vals {
+		for j, prefix := range prefixes {
+			pre := append([]byte(nil), prefix...)
+
+			encoded := AppendEncode(prefix, val)
+			tst := string(pre) + EncodeToString(val)
+			if string(encoded) != tst {
+				t.Errorf("data not equal: %d/%d: %v\n tst=<%q>\n got <%q>\n",
+					i, j, val, tst, encoded)
+			}
+
+			decoded, err := AppendDecode(prefix, encoded[len(pre):])
+			if err != nil {
+				t.Errorf("bad err: %d/%d: %v\n", i, j, err)
+			}
+			if !bytes.Equal(decoded, append(pre, val...)) {
+				t.Errorf("decoded not equal: %d/%d: %v\n got <%q>\n",
+					i, j, val, decoded)
+			}
+		}
+	}
+
+	decoded, err := AppendDecode([]byte("x"), []byte("1x.56."))
+	if err == nil || string(decoded) != "xa" {
+		t.Errorf("bad err: %q made %v\n", decoded, err)
+	}
+}
+
+func TestBase50AppendAllocs(t *testing.T) {
+	val := []byte("abcdefghijklmnopqrstuvwxyz")
+	encoded := EncodeToBytes(val)
+	ebuf := make([]byte, 0, 64)
+	dbuf := make([]byte, 0, 64)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ebuf = AppendEncode(ebuf[:0], val)
+		dbuf, _ = AppendDecode(dbuf[:0], encoded)
+	})
+	if allocs != 0 {
+		t.Errorf("bad allocs: %v\n", allocs)
+	}
+}
+
+func TestBase50Valid(t *testing.T) {
+	data := []string{"", " ", "1x", "1x.", "1x. 11.", "H1jP5 eefyh 112 s",
+		"H1jP5eefyh.", "0.0.0.", "!", "1x.1O.", "56.", "zzzzzzzzzz", ".",
+		"1x..", "1x. ."}
+
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithCheck(),
+		StdEncoding.WithGroupCheck()} {
+		for i := range data {
+			decoded, derr := enc.DecodeString(data[i])
+			n, err := enc.DecodedLenExact([]byte(data[i]))
+			if (err == nil) != (derr == nil) ||
+				(err != nil && err.Error() != derr.Error()) {
+				t.Errorf("bad err: %d: %q made %v not %v\n", i, data[i], err, derr)
+			}
+			if err == nil && n != len(decoded) {
+				t.Errorf("bad len: %d: %q made %d not %d\n",
+					i, data[i], n, len(decoded))
+			}
+			if err := enc.Valid([]byte(data[i])); (err == nil) != (derr == nil) {
+				t.Errorf("bad valid: %d: %q made %v\n", i, data[i], err)
+			}
+		}
+
+		val := []byte("abcdefghijklmnopqrstuvwxyz")
+		for l := 0; l <= len(val); l++ {
+			encoded := enc.EncodeToBytes(val[:l])
+			if n, err := enc.DecodedLenExact(encoded); err != nil || n != l {
+				t.Errorf("bad len: %d: <%s> made %d %v\n", l, encoded, n, err)
+			}
+		}
+	}
+
+	encoded := EncodeToBytes([]byte("abcdefghijklmnopqrstuvwxyz"))
+	allocs := testing.AllocsPerRun(100, func() {
+		if Valid(encoded) != nil {
+			t.Errorf("not valid: <%s>\n", encoded)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("bad allocs: %v\n", allocs)
+	}
+}
+
+func BenchmarkAppendEncode(b *testing.B) {
+	val := []byte("abcdefghijklmnopqrstuvwxyz")
+	buf := make([]byte, 0, 64)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(val)))
+	for i := 0; i < b.N; i++ {
+		buf = AppendEncode(buf[:0], val)
+	}
+}
+
+func BenchmarkAppendDecode(b *testing.B) {
+	val := EncodeToBytes([]byte("abcdefghijklmnopqrstuvwxyz"))
+	buf := make([]byte, 0, 64)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(val)))
+	for i := 0; i < b.N; i++ {
+		buf, _ = AppendDecode(buf[:0], val)
+	}
+}
+
+func BenchmarkEncodeToString(b *testing.B) {
+	val := []byte("abcdefghijklmnopqrstuvwxyz")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(val)))
+	for i := 0; i < b.N; i++ {
+		_ = EncodeToString(val)
+	}
+}
+
+func TestBase50DecodeMap(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		c := byte(i)
+		v := StdEncoding.decodeMap[c]
+		switch {
+		case c == '.':
+			if v != stopClass {
+				t.Errorf("bad class: %#U made %#x\n", rune(c), v)
+			}
+		case skipChar(c):
+			if v != skipClass {
+				t.Errorf("bad class: %#U made %#x\n", rune(c), v)
+			}
+		case strings.IndexByte(Alphabet, c) >= 0:
+			if Alphabet[v] != c {
+				t.Errorf("bad value: %#U made %d\n", rune(c), v)
+			}
+		default:
+			if v != invalidChar {
+				t.Errorf("bad class: %#U made %#x\n", rune(c), v)
+			}
+		}
+	}
+}
+
+func TestBase50Divmod50x5(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	nums := []uint64{0, 1, pow50x5 - 1, pow50x5, pow50x5 + 1,
+		0xFFFFFFFFFFFFFF, orderedMax, 97656249999999999}
+	for i := 0; i < 10000; i++ {
+		nums = append(nums, uint64(rnd.Int63n(97656250000000000)))
+	}
+
+	for _, num := range nums {
+		hi, lo := divmod50x5(num)
+		if uint64(hi) != num/pow50x5 || uint64(lo) != num%pow50x5 {
+			t.Errorf("bad divmod: %d made %d %d\n", num, hi, lo)
+		}
+	}
+}
+
+func TestBase50FoldGroup(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		var vals [10]byte
+		grp := make([]byte, 10)
+		for j := range vals {
+			vals[j] = byte(rnd.Intn(50))
+			if i == 0 {
+				vals[j] = 49
+			}
+			grp[j] = Alphabet[vals[j]]
+		}
+
+		hi, _ := StdEncoding.decodeHalf(grp[:5])
+		lo, _ := StdEncoding.decodeHalf(grp[5:])
+		if num := foldGroup(&vals); num != uint64(hi)*pow50x5+uint64(lo) {
+			t.Errorf("bad fold: <%s> made %d\n", grp, num)
+		}
+	}
+}
+
+func TestBase50SwarClean(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		c := byte(i)
+		clean := c >= '0' && c < 0x80 && c != '_'
+		for pos := uint(0); pos < 64; pos += 8 {
+			x := 0x3030303030303030&^(0xFF<<pos) | uint64(c)<<pos
+			if swarClean(x) != clean {
+				t.Errorf("bad swarClean: %#U at %d\n", rune(c), pos/8)
+			}
+		}
+	}
+}
+
+func TestBase50DecodeRun(t *testing.T) {
+	val := []byte("abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithGroupCheck(),
+		StdEncoding.Dense(), StdEncoding.Strict()} {
+		encoded := enc.EncodeToBytes(val)
+
+		// Every bad character is found where it is, in the fast path or
+		// not.
+		for i := 0; i < len(encoded); i++ {
+			for _, c := range []byte{'!', 'B', 0x80} {
+				bad := append([]byte(nil), encoded...)
+				bad[i] = c
+				_, err := enc.Decode(make([]byte, len(val)), bad)
+				var derr *DecodeError
+				if !errors.As(err, &derr) || !errors.Is(err, ErrInvalidChar) ||
+					derr.Offset != i {
+					t.Errorf("bad err: %d %q made %v\n", i, c, err)
+				}
+			}
+
+			if enc.strict {
+				continue
+			}
+			spaced := append(append(append([]byte(nil), encoded[:i]...), ' '),
+				encoded[i:]...)
+			decoded, err := enc.Decode(make([]byte, len(val)), spaced)
+			if err != nil || !bytes.Equal(decoded, val) {
+				t.Errorf("bad decode: <%s> made %v\n", spaced, err)
+			}
+		}
+	}
+
+	// Decoding in place writes over the input, so an error after a group is
+	// decoded (the size limit) is returned from the fast path.
+	for _, max := range []int64{5, 7, 30} {
+		_, err := StdEncoding.WithMaxSize(max).DecodeString(EncodeToString(val))
+		var derr *DecodeError
+		if !errors.Is(err, ErrSizeLimit) || !errors.As(err, &derr) ||
+			derr.Offset != int(max)/7*10 {
+			t.Errorf("bad err: %d made %v\n", max, err)
+		}
+	}
+}
+
+// benchSizes are the input sizes for BenchmarkEncode and BenchmarkDecode.
+var benchSizes = []int{8, 64, 1024, 64 * 1024}
+
+// benchCodecs compares base50 with the encodings in the standard library.
+var benchCodecs = []struct {
+	name   string
+	encode func(dst, src []byte) []byte
+	decode func(dst, src []byte) ([]byte, error)
+}{
+	{"base50",
+		func(dst, src []byte) []byte { return Encode(dst, src) },
+		func(dst, src []byte) ([]byte, error) { return Decode(dst, src) }},
+	{"base64",
+		func(dst, src []byte) []byte {
+			base64.StdEncoding.Encode(dst, src)
+			return dst[:base64.StdEncoding.EncodedLen(len(src))]
+		},
+		func(dst, src []byte) ([]byte, error) {
+			n, err := base64.StdEncoding.Decode(dst, src)
+			return dst[:n], err
+		}},
+	{"hex",
+		func(dst, src []byte) []byte {
+			return dst[:hex.Encode(dst, src)]
+		},
+		func(dst, src []byte) ([]byte, error) {
+			n, err := hex.Decode(dst, src)
+			return dst[:n], err
+		}},
+}
+
+func benchData(n int) []byte {
+	val := make([]byte, n)
+	rand.New(rand.NewSource(1)).Read(val)
+	return val
+}
+
+func BenchmarkEncode(b *testing.B) {
+	for _, c := range benchCodecs {
+		for _, size := range benchSizes {
+			val := benchData(size)
+			dst := make([]byte, 2*size+16)
+			b.Run(fmt.Sprintf("%s/%d", c.name, size), func(b *testing.B) {
+				b.SetBytes(int64(size))
+				for i := 0; i < b.N; i++ {
+					c.encode(dst, val)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, c := range benchCodecs {
+		for _, size := range benchSizes {
+			encoded := c.encode(make([]byte, 2*size+16), benchData(size))
+			dst := make([]byte, size+16)
+			b.Run(fmt.Sprintf("%s/%d", c.name, size), func(b *testing.B) {
+				b.SetBytes(int64(size))
+				for i := 0; i < b.N; i++ {
+					if _, err := c.decode(dst, encoded); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkEncodeGroup and BenchmarkDecodeGroup are for the code that needs
+// 64-bit math, which is much slower on 32-bit CPUs. Compare with GOARCH=386.
+func BenchmarkEncodeGroup(b *testing.B) {
+	val := []byte("abcdefg")
+	var dst [10]byte
+
+	b.SetBytes(int64(len(val)))
+	for i := 0; i < b.N; i++ {
+		_, _ = StdEncoding.encodeBytesSuffix(dst[:], val)
+	}
+}
+
+func BenchmarkDecodeGroup(b *testing.B) {
+	grp := EncodeToBytes([]byte("abcdefg"))
+	var dst [7]byte
+
+	b.SetBytes(int64(len(dst)))
+	for i := 0; i < b.N; i++ {
+		if _, err := StdEncoding.decodeGroup(dst[:], grp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBase50FixedLength(t *testing.T) {
+	data := []struct {
+		val []byte
+		enc string
+	}{
+		{[]byte{0x00}, "00."},
+		{[]byte{0x31}, "0z."},
+		{[]byte{0xFF}, "55."},
+		{[]byte{0, 0, 0}, "00000."},
+		{[]byte{0, 0, 0, 0, 0}, "00000000."},
+		{[]byte("abcdefg"), "H1jP5eefyh"},
+	}
+
+	fenc := StdEncoding.FixedLength()
+	for i := range data {
+		encoded := fenc.EncodeToString(data[i].val)
+		if encoded != data[i].enc {
+			t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+				i, data[i].enc, encoded)
+		}
+		decoded, err := fenc.DecodeString(encoded)
+		if err != nil || !bytes.Equal(decoded, data[i].val) {
+			t.Errorf("bad decode: %d: <%s> made %v\n", i, encoded, err)
+		}
+	}
+
+	// The length only depends on the length of the input.
+	for l := 0; l <= 21; l++ {
+		for _, b := range []byte{0x00, 0x01, 0x80, 0xFF} {
+			val := bytes.Repeat([]byte{b}, l)
+			encoded := fenc.EncodeToBytes(val)
+			if len(encoded) != fenc.EncodeLen(l) {
+				t.Errorf("bad len: %d/%#x: <%s>\n", l, b, encoded)
+			}
+			if _, err := fenc.Strict().Decode(make([]byte, l), encoded); err != nil {
+				t.Errorf("bad strict decode: %d/%#x: <%s> made %v\n",
+					l, b, encoded, err)
+			}
+		}
+	}
+
+	for _, s := range []string{"0.", "z.", "0000.", "0000000."} {
+		if _, err := fenc.DecodeString(s); !errors.Is(err, ErrNonCanonical) {
+			t.Errorf("bad err: %q made %v\n", s, err)
+		}
+	}
+}
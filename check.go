@@ -0,0 +1,165 @@
+package base50
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrCheck is for WithCheck() messages where the check character doesn't match
+// the rest of the message, Eg. a character was mistyped.
+var ErrCheck = errors.New("base50: check character mismatch")
+
+// luhn computes a Luhn mod N check character, see:
+// https://en.wikipedia.org/wiki/Luhn_mod_N_algorithm
+// The Luhn algorithm works from right to left, so to work from left to right
+// we keep a sum for the last character being at an even and odd position.
+type luhn struct {
+	sum [2]int
+	n   int // number of characters added
+}
+
+// add adds the character with value v to the check.
+func (l *luhn) add(v byte) {
+	for p := range l.sum {
+		factor := 1
+		if l.n%2 == p {
+			factor = 2
+		}
+		addend := factor * int(v)
+		l.sum[p] += addend/50 + addend%50
+	}
+	l.n++
+}
+
+// addChars adds the characters in src, which must all be in the alphabet of
+// enc, to the check.
+func (l *luhn) addChars(enc *Encoding, src []byte) {
+	for _, c := range src {
+		l.add(enc.decodeMap[c])
+	}
+}
+
+// check returns the value of the check character for what has been added.
+func (l *luhn) check() byte {
+	// The rightmost character has a factor of 2.
+	sum := l.sum[(l.n+1)%2]
+	return byte((50 - sum%50) % 50)
+}
+
+// appendCheck writes the check character l and the stop character at dst[n:],
+// returning the new length.
+func (enc *Encoding) appendCheck(dst []byte, n int, l *luhn) int {
+	dst[n] = enc.encode[l.check()]
+	dst[n+1] = '.'
+	return n + 2
+}
+
+// WithCheck returns a copy of enc where each encoded message ends with a
+// check character, computed over the other characters with the Luhn mod N
+// algorithm, and then a stop character. Eg. "1x." becomes "1x4.".
+// This catches all single character mistakes and most swaps of adjacent
+// characters, which would otherwise often decode to the wrong data.
+//
+// When decoding, the last character before each stop character (or the end of
+// the input) is the check character. Decode only returns data from messages
+// where the check matches. The streaming decoder returns data as it is read,
+// so the data before an ErrCheck might be wrong. This can't be used with
+// WithGroupCheck() or Ordered().
+func (enc *Encoding) WithCheck() *Encoding {
+	e := *enc
+	e.check = true
+	e.groupCheck = false
+	e.ordered = false
+	return &e
+}
+
+var checkEncoding = StdEncoding.WithCheck()
+
+// EncodeWithCheck encodes src into dst like Encode(), but with a check
+// character, see WithCheck(). dst must be at least
+// StdEncoding.WithCheck().EncodeLen(len(src)) bytes.
+func EncodeWithCheck(dst, src []byte) []byte {
+	return checkEncoding.Encode(dst, src)
+}
+
+// DecodeWithCheck decodes src into dst like Decode(), but with a check
+// character, see WithCheck(). If the check doesn't match the error is
+// ErrCheck.
+func DecodeWithCheck(dst, src []byte) ([]byte, error) {
+	return checkEncoding.Decode(dst, src)
+}
+
+// checkChar returns the check character for the encoded characters in grp.
+func (enc *Encoding) checkChar(grp []byte) byte {
+	var l luhn
+	l.addChars(enc, grp)
+	return enc.encode[l.check()]
+}
+
+// WithGroupCheck returns a copy of enc where each group of encoded characters
+// ends with its own check character, see WithCheck(). So full groups are 11
+// characters and the shortened last group is followed by its check character
+// and then the stop character. Eg. "H1jP5eefyh112sa." becomes
+// "H1jP5eefyhP112saq.". This can't be used with WithCheck().
+//
+// When decoding, a group that doesn't match its check character is decoded as
+// zero bytes and decoding continues, then the error is a GroupCheckError
+// saying which groups didn't match. So the bytes from all the other groups
+// can still be used.
+func (enc *Encoding) WithGroupCheck() *Encoding {
+	e := *enc
+	e.groupCheck = true
+	e.check = false
+	return &e
+}
+
+// GroupCheckError values describe the groups which didn't match their check
+// character, when decoding WithGroupCheck() encodings. Each DecodeError has
+// the offset of the group in the input and the offset of the (zeroed) bytes
+// in the output.
+type GroupCheckError []*DecodeError
+
+func (e GroupCheckError) Error() string {
+	groups := make([]string, len(e))
+	for i := range e {
+		groups[i] = fmt.Sprint(e[i].Group)
+	}
+	return fmt.Sprintf("%v in groups: %s", ErrCheck, strings.Join(groups, ", "))
+}
+
+// Is makes GroupCheckError match ErrCheck.
+func (e GroupCheckError) Is(target error) bool {
+	return target == ErrCheck
+}
+
+// flushGroupCheck is flush() for WithGroupCheck() encodings, the last
+// character in grp is the check character.
+func (g *groupDecoder) flushGroupCheck(dst []byte) (int, error) {
+	if g.ngrp < 2 {
+		return 0, g.error(g.start, ErrTruncatedGroup)
+	}
+
+	grp := g.grp[:g.ngrp-1]
+	if g.enc.checkChar(grp) == g.enc.encode[g.enc.decodeMap[g.grp[g.ngrp-1]]] {
+		return g.flushed(g.enc.decodeGroup(dst, grp))
+	}
+
+	n := g.enc.DecodeLen(len(grp))
+	if len(dst) < n {
+		return 0, g.error(g.start, io.ErrShortBuffer)
+	}
+	if err := g.limitSize(g.start, n); err != nil {
+		return 0, err
+	}
+	for i := range dst[:n] {
+		dst[i] = 0
+	}
+	g.failed = append(g.failed, g.error(g.start, ErrCheck).(*DecodeError))
+	g.ngrp = 0
+	g.group++
+	g.count += n
+	g.short = n < g.enc.groupBytes()
+	return n, nil
+}
@@ -0,0 +1,244 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBase50Check(t *testing.T) {
+	cenc := StdEncoding.WithCheck()
+
+	data := []struct {
+		val []byte
+		enc string
+	}{
+		{[]byte{}, ""},
+		{[]byte("abc"), "112saq."},
+		{[]byte("abcdefg"), "H1jP5eefyhP."},
+	}
+	for i := range data {
+		encoded := cenc.EncodeToString(data[i].val)
+		if encoded != data[i].enc {
+			t.Errorf("data not equal: %d: %v\n tst=<%s>\n got <%s>\n",
+				i, data[i].val, data[i].enc, encoded)
+		}
+	}
+
+	val := []byte("abcdefghijklmnopqrstuvwxyz")
+	for l := 0; l <= len(val); l++ {
+		dst := make([]byte, cenc.EncodeLen(l))
+		encoded := EncodeWithCheck(dst, val[:l])
+		plain := len(EncodeToString(val[:l]))
+		if l > 0 && len(encoded) != plain+1 && len(encoded) != plain+2 {
+			t.Errorf("bad len: %d: <%s>\n", l, encoded)
+		}
+		if l > 0 && encoded[len(encoded)-1] != '.' {
+			t.Errorf("no stop character: %d: <%s>\n", l, encoded)
+		}
+
+		decoded, err := DecodeWithCheck(make([]byte, DecodeLen(len(encoded))),
+			encoded)
+		if err != nil {
+			t.Errorf("bad err: %d: <%s> made %v\n", l, encoded, err)
+		}
+		if !bytes.Equal(decoded, val[:l]) {
+			t.Errorf("decoded not equal: %d: <%s>\n got <%s>\n",
+				l, encoded, decoded)
+		}
+
+		// The stop character is optional at the end.
+		if l > 0 {
+			decoded, err = cenc.DecodeString(string(encoded[:len(encoded)-1]))
+			if err != nil || !bytes.Equal(decoded, val[:l]) {
+				t.Errorf("bad decode: %d: <%s> made %v\n", l, encoded, err)
+			}
+		}
+
+		// Streaming should be the same.
+		var bb bytes.Buffer
+		w := cenc.NewEncoder(&bb)
+		for _, c := range val[:l] {
+			if _, err := w.Write([]byte{c}); err != nil {
+				t.Fatalf("write err: %v\n", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("close err: %v\n", err)
+		}
+		if bb.String() != string(encoded) {
+			t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+				l, encoded, bb.String())
+		}
+
+		r := iotest.OneByteReader(bytes.NewReader(encoded))
+		decoded, err = ioutil.ReadAll(cenc.NewDecoder(r))
+		if err != nil || !bytes.Equal(decoded, val[:l]) {
+			t.Errorf("bad stream decode: %d: <%s> made %v\n", l, encoded, err)
+		}
+	}
+}
+
+func TestBase50CheckConcat(t *testing.T) {
+	cenc := StdEncoding.WithCheck()
+
+	encoded := cenc.EncodeToString([]byte("abc")) + "\n" +
+		cenc.EncodeToString([]byte("abcdefg")) + "\n" +
+		cenc.EncodeToString([]byte("x"))
+	decoded, err := cenc.DecodeString(encoded)
+	if err != nil || string(decoded) != "abcabcdefgx" {
+		t.Errorf("bad decode: <%s> made %q %v\n", encoded, decoded, err)
+	}
+
+	// Only the messages before the bad one are returned.
+	bad := strings.Replace(encoded, "eefyh", "eegyh", 1)
+	decoded, err = cenc.DecodeString(bad)
+	if !errors.Is(err, ErrCheck) || string(decoded) != "abc" {
+		t.Errorf("bad decode: <%s> made %q %v\n", bad, decoded, err)
+	}
+	var derr *DecodeError
+	if !errors.As(err, &derr) || derr.Offset != 18 || derr.DecodedOffset != 3 {
+		t.Errorf("bad err: %#v\n", err)
+	}
+
+	data := []string{".", "112saq..", "."}
+	for i := range data {
+		if _, err := cenc.DecodeString(data[i]); !errors.Is(err, ErrTruncatedGroup) {
+			t.Errorf("bad err: %d: <%s> made %v\n", i, data[i], err)
+		}
+	}
+}
+
+func TestBase50CheckTypos(t *testing.T) {
+	cenc := StdEncoding.WithCheck()
+
+	val := []byte("abcdefghijklmnopq")
+	encoded := cenc.EncodeToBytes(val)
+	chars := encoded[:len(encoded)-1]
+
+	// Every single character substitution is found.
+	for i := range chars {
+		for j := 0; j < len(Alphabet); j++ {
+			if chars[i] == Alphabet[j] {
+				continue
+			}
+			bad := append([]byte(nil), encoded...)
+			bad[i] = Alphabet[j]
+
+			decoded, err := cenc.Decode(make([]byte, len(bad)), bad)
+			if err == nil {
+				t.Errorf("no err: <%s> made %q\n", bad, decoded)
+			}
+			if len(decoded) != 0 {
+				t.Errorf("data returned: <%s> made %q\n", bad, decoded)
+			}
+		}
+	}
+
+	// Most adjacent transpositions are found.
+	same, found := 0, 0
+	for i := 0; i < len(Alphabet); i++ {
+		for j := 0; j < len(Alphabet); j++ {
+			if i == j {
+				continue
+			}
+			msg := []byte{Alphabet[i], Alphabet[j], '0', '0', '0', '0'}
+			var l luhn
+			l.addChars(StdEncoding, msg)
+			msg = append(msg, 0, 0)
+			msg = msg[:StdEncoding.appendCheck(msg, len(msg)-2, &l)]
+
+			msg[0], msg[1] = msg[1], msg[0]
+			if _, err := cenc.DecodeString(string(msg)); errors.Is(err, ErrCheck) {
+				found++
+			} else {
+				same++
+			}
+		}
+	}
+	if found < same*20 {
+		t.Errorf("too many transpositions not found: %d/%d\n", same, found+same)
+	}
+}
+
+func TestBase50GroupCheck(t *testing.T) {
+	genc := StdEncoding.WithGroupCheck()
+
+	if genc.EncodeToString([]byte("abcdefgabc")) != "H1jP5eefyhP112saq." {
+		t.Errorf("data not equal: <%s>\n",
+			genc.EncodeToString([]byte("abcdefgabc")))
+	}
+
+	val := []byte("abcdefghijklmnopqrstuvwxyz")
+	for l := 0; l <= len(val); l++ {
+		encoded := genc.EncodeToBytes(val[:l])
+		if len(encoded) > genc.EncodeLen(l) {
+			t.Errorf("bad len: %d: <%s>\n", l, encoded)
+		}
+
+		decoded, err := genc.DecodeString(string(encoded))
+		if err != nil || !bytes.Equal(decoded, val[:l]) {
+			t.Errorf("bad decode: %d: <%s> made %q %v\n",
+				l, encoded, decoded, err)
+		}
+
+		var bb bytes.Buffer
+		w := genc.NewEncoder(&bb)
+		if _, err := w.Write(val[:l]); err != nil {
+			t.Fatalf("write err: %v\n", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("close err: %v\n", err)
+		}
+		if bb.String() != string(encoded) {
+			t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+				l, encoded, bb.String())
+		}
+	}
+
+	// Break the 2nd and 4th groups, the rest should still decode.
+	encoded := genc.EncodeToString(val)
+	bad := []byte(encoded)
+	bad[11+3] = '0'
+	bad[33+3] = bad[33+4]
+	tst := append([]byte(nil), val...)
+	copy(tst[7:14], make([]byte, 7))
+	copy(tst[21:], make([]byte, 5))
+
+	for j, decode := range []func() ([]byte, error){
+		func() ([]byte, error) { return genc.DecodeString(string(bad)) },
+		func() ([]byte, error) {
+			r := iotest.OneByteReader(bytes.NewReader(bad))
+			return ioutil.ReadAll(genc.NewDecoder(r))
+		},
+	} {
+		decoded, err := decode()
+		if !bytes.Equal(decoded, tst) {
+			t.Errorf("decoded not equal: %d\n tst=<%q>\n got <%q>\n",
+				j, tst, decoded)
+		}
+		if !errors.Is(err, ErrCheck) {
+			t.Errorf("bad err: %d: %v\n", j, err)
+		}
+		var gerr GroupCheckError
+		if !errors.As(err, &gerr) || len(gerr) != 2 {
+			t.Fatalf("bad err: %d: %#v\n", j, err)
+		}
+		if gerr[0].Group != 1 || gerr[0].Offset != 11 || gerr[0].DecodedOffset != 7 {
+			t.Errorf("bad err: %d: %#v\n", j, gerr[0])
+		}
+		if gerr[1].Group != 3 || gerr[1].Offset != 33 || gerr[1].DecodedOffset != 21 {
+			t.Errorf("bad err: %d: %#v\n", j, gerr[1])
+		}
+	}
+
+	if _, err := genc.DecodeString("1."); !errors.Is(err, ErrTruncatedGroup) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if genc.WithCheck().groupCheck || !genc.WithCheck().WithGroupCheck().groupCheck {
+		t.Errorf("WithCheck() and WithGroupCheck() mixed\n")
+	}
+}
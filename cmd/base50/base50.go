@@ -12,16 +12,37 @@ import (
 	"github.com/james-antill/base50"
 )
 
+// readAll reads everything from r, upto max bytes (if max > 0).
+func readAll(r io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		return ioutil.ReadAll(r)
+	}
+
+	bin, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err == nil && int64(len(bin)) > max {
+		err = fmt.Errorf("input is bigger than %d bytes, see -m", max)
+	}
+	return bin, err
+}
+
 func main() {
 	var (
 		err error
 		bin []byte
 
-		help   = flag.Bool("h", false, "display this message")
-		input  = flag.String("i", "", `input file (use: "-" for stdin, "" for arguments)`)
-		output = flag.String("o", "-", `output file (use: "-" for stdout)`)
-		base16 = flag.Bool("x", false, `treat input/output as base16`)
-		decode = flag.Bool("d", false, `decode input`)
+		help    = flag.Bool("h", false, "display this message")
+		input   = flag.String("i", "", `input file (use: "-" for stdin, "" for arguments)`)
+		output  = flag.String("o", "-", `output file (use: "-" for stdout)`)
+		base16  = flag.Bool("x", false, `treat input/output as base16`)
+		decode  = flag.Bool("d", false, `decode input`)
+		lenient = flag.Bool("l", false, `decode look-alike characters, Eg. O as 0`)
+		unicode = flag.Bool("n", false, `normalize Unicode spaces/digits/letters before decoding`)
+		check   = flag.Bool("c", false, `add/verify a check character`)
+		maxIn   = flag.Int64("m", 64<<20, `maximum input size in bytes when decoding (0 for no limit)`)
+		maxSkip = flag.Int64("s", 0, `maximum whitespace/underbars skipped when decoding (0 for no limit)`)
+		wrap    = flag.Int("w", 0, `wrap encoded lines after N characters (0 for no wrapping)`)
+		group   = flag.Int("g", 0, `separate encoded characters into groups of N (0 for no groups)`)
+		sep     = flag.String("gs", "_", `group separator (use: "_", " " or "-")`)
 	)
 
 	flag.Parse()
@@ -36,6 +57,26 @@ func main() {
 		os.Exit(1)
 	}
 
+	enc := base50.StdEncoding
+	if *check {
+		enc = enc.WithCheck()
+	}
+	if *wrap > 0 {
+		enc = enc.WithLineWrap(*wrap)
+	}
+	if *group > 0 {
+		if len(*sep) != 1 {
+			fmt.Fprintf(os.Stderr, "Invalid group separator: %q\n", *sep)
+			flag.Usage()
+			os.Exit(1)
+		}
+		if enc, err = enc.WithSeparator((*sep)[0], *group); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			flag.Usage()
+			os.Exit(1)
+		}
+	}
+
 	var fin io.Reader
 	var fout io.Writer
 	fin, fout = os.Stdin, os.Stdout
@@ -56,6 +97,12 @@ func main() {
 
 	de16input := !*decode && *base16
 
+	// Only decoding reads untrusted input, anything can be encoded.
+	var limit int64
+	if *decode {
+		limit = *maxIn
+	}
+
 	if de16input {
 		fin = hex.NewDecoder(fin)
 	}
@@ -76,19 +123,32 @@ func main() {
 			hex.Decode(dst, bin)
 			bin = dst
 		}
-	} else if bin, err = ioutil.ReadAll(fin); err != nil {
+	} else if bin, err = readAll(fin, limit); err != nil {
 		fmt.Fprintln(os.Stderr, "read input err:", err)
 		os.Exit(1)
 	}
 
 	if *decode {
 		lastBytePos := len(bin) - 1
-		if bin[lastBytePos] == '\n' {
+		if lastBytePos >= 0 && bin[lastBytePos] == '\n' {
 			bin = bin[:lastBytePos]
 		}
 
-		decoded := make([]byte, base50.DecodeLen(len(bin)))
-		decoded, err := base50.Decode(decoded, bin)
+		if *unicode {
+			bin = base50.Normalize(bin)
+		}
+
+		if *lenient {
+			enc = enc.Lenient()
+			for _, s := range enc.Substitutions(bin) {
+				fmt.Fprintf(os.Stderr, "decode input: %q read as %q at offset %d\n",
+					s.From, s.To, s.Offset)
+			}
+		}
+
+		enc = enc.WithMaxSkipped(*maxSkip)
+		decoded := make([]byte, enc.DecodeLen(len(bin)))
+		decoded, err := enc.Decode(decoded, bin)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "decode input err:", err)
 			os.Exit(1)
@@ -106,5 +166,5 @@ func main() {
 		os.Exit(0)
 	}
 
-	fmt.Fprintln(fout, base50.EncodeToString(bin))
+	fmt.Fprintln(fout, enc.EncodeToString(bin))
 }
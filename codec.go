@@ -0,0 +1,356 @@
+package base50
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
+)
+
+// A Codec is an encoding/decoding scheme like base50, but for an alphabet of
+// any size from 2 to 92 characters. Like base50 the input is split into groups
+// of bytes, which are converted independently into a number in base N
+// with a fixed number of characters. The size of the groups is worked out
+// from the size of the alphabet, using the most bytes per character that fits
+// in 64 bits, Eg. base50 is 7 bytes in 10 characters and base36 is 7 bytes in
+// 11 characters. The last group is shorter, and a character shorter again
+// when the value is small enough, and is followed by a stop character (unless
+// it's WithoutStop()).
+//
+// A Codec with Alphabet is the same as StdEncoding, but it doesn't have most
+// of the options of an Encoding.
+type Codec struct {
+	encode []byte
+	radix  uint64
+	bytes  int       // number of bytes in a full group
+	chars  int       // number of characters in a full group
+	tail   [8]int    // number of characters for a last group of n bytes
+	short  [8]uint64 // values less than this have a character less
+	lens   [65]int   // number of bytes for n characters, -1 for invalid
+	noStop bool      // WithoutStop() doesn't write the stop character
+
+	// enc has the decodeMap and limits, and uses the groups of the Codec, so
+	// the decoders and the stream encoder are the same as an Encoding.
+	enc *Encoding
+}
+
+// charsFor returns the number of base radix characters needed for n bytes.
+func charsFor(radix, n int) int {
+	max := new(big.Int).Lsh(big.NewInt(1), uint(8*n))
+	val := big.NewInt(1)
+	r := big.NewInt(int64(radix))
+
+	c := 0
+	for val.Cmp(max) < 0 {
+		val.Mul(val, r)
+		c++
+	}
+	return c
+}
+
+// NewCodec returns a new Codec defined by the given alphabet, which must be
+// 2 to 92 unique printable ASCII characters. Like NewEncoding(), the alphabet
+// can't contain the stop character '.', the underbar or whitespace.
+func NewCodec(alphabet string) (*Codec, error) {
+	if len(alphabet) < 2 || len(alphabet) > 92 {
+		return nil, AlphabetError(fmt.Sprintf("length is %d not 2 to 92",
+			len(alphabet)))
+	}
+
+	c := &Codec{encode: []byte(alphabet), radix: uint64(len(alphabet))}
+	c.enc = &Encoding{codec: c}
+	if err := alphabetMap(&c.enc.decodeMap, alphabet); err != nil {
+		return nil, err
+	}
+
+	// Find the most bytes per character, where a full group can't be confused
+	// with a shortened one.
+	for b := 1; b <= 8; b++ {
+		n := charsFor(len(alphabet), b)
+		if b > 1 && charsFor(len(alphabet), b-1) == n {
+			continue
+		}
+		if c.bytes == 0 || b*c.chars > c.bytes*n {
+			c.bytes, c.chars = b, n
+		}
+	}
+
+	for i := range c.lens {
+		c.lens[i] = -1
+	}
+	c.lens[c.chars] = c.bytes
+	for b := 1; b < c.bytes; b++ {
+		c.tail[b] = charsFor(len(alphabet), b)
+		c.lens[c.tail[b]] = b
+
+		// Like base50, a character can be dropped when the value is small
+		// enough and that length isn't used for fewer bytes.
+		if c.tail[b]-1 > c.tail[b-1] {
+			c.short[b] = 1
+			for i := 1; i < c.tail[b]; i++ {
+				c.short[b] *= c.radix
+			}
+			c.lens[c.tail[b]-1] = b
+		}
+	}
+
+	return c, nil
+}
+
+func mustNewCodec(alphabet string) *Codec {
+	c, err := NewCodec(alphabet)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Base36 is a case insensitive Codec using digits and lower case letters.
+// Use Base36.WithoutStop() for DNS labels and email local parts, which can't
+// have (or end with) a '.'.
+var Base36 = mustNewCodec("0123456789abcdefghijklmnopqrstuvwxyz").IgnoreCase()
+
+// Base62 is a Codec using digits, upper case and lower case letters.
+var Base62 = mustNewCodec("0123456789" + "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz")
+
+// clone returns a copy of c, with its own copy of enc.
+func (c *Codec) clone() *Codec {
+	n := *c
+	e := *c.enc
+	e.codec = &n
+	n.enc = &e
+	return &n
+}
+
+// IgnoreCase returns a copy of c which decodes letters that aren't in the
+// alphabet as the same letter in the other case, if that is in the alphabet.
+func (c *Codec) IgnoreCase() *Codec {
+	n := c.clone()
+
+	m := &n.enc.decodeMap
+	for ch := 'A'; ch <= 'Z'; ch++ {
+		up, low := byte(ch), byte(ch-'A'+'a')
+		switch {
+		case m[up] == invalidChar:
+			m[up] = m[low]
+		case m[low] == invalidChar:
+			m[low] = m[up]
+		}
+	}
+
+	return n
+}
+
+// WithoutStop returns a copy of c which doesn't write the stop character
+// after a shortened last group, so the output is only characters from the
+// alphabet. Decoding doesn't need the stop character at the end of the input,
+// but without it encodings can't be concatenated.
+func (c *Codec) WithoutStop() *Codec {
+	n := c.clone()
+	n.noStop = true
+	return n
+}
+
+// WithMaxSize returns a copy of c where decoding more than n bytes is an
+// error, see WithMaxSize().
+func (c *Codec) WithMaxSize(n int64) *Codec {
+	nc := c.clone()
+	nc.enc.maxSize = n
+	return nc
+}
+
+// WithMaxSkipped returns a copy of c where skipping more than n characters
+// when decoding is an error, see WithMaxSkipped().
+func (c *Codec) WithMaxSkipped(n int64) *Codec {
+	nc := c.clone()
+	nc.enc.maxSkipped = n
+	return nc
+}
+
+// Group returns the number of bytes, and characters, in a full group.
+func (c *Codec) Group() (bytes, chars int) {
+	return c.bytes, c.chars
+}
+
+// EncodeLen returns the maximum length in bytes of the encoding of an input
+// buffer of length x, see EncodeLen().
+func (c *Codec) EncodeLen(x int) int {
+	if x <= 0 {
+		return 0
+	}
+
+	n := x / c.bytes * c.chars
+	if rem := x % c.bytes; rem > 0 {
+		n += c.tail[rem]
+		if !c.noStop {
+			n++
+		}
+	}
+	return n
+}
+
+// DecodeLen returns the maximum length in bytes of the decoded data
+// corresponding to x bytes of encoded data, see DecodeLen().
+func (c *Codec) DecodeLen(x int) int {
+	if x < 0 {
+		return 0
+	}
+
+	n := x / c.chars * c.bytes
+	for rem := x % c.chars; rem > 0; rem-- {
+		if c.lens[rem] >= 0 {
+			return n + c.lens[rem]
+		}
+	}
+	return n
+}
+
+// encodeGroup encodes the (upto) full group of bytes in src into dst,
+// returning the number of bytes written.
+func (c *Codec) encodeGroup(dst, src []byte) int {
+	var num uint64
+	for _, b := range src {
+		num <<= 8
+		num += uint64(b)
+	}
+
+	outb := c.chars
+	if len(src) < c.bytes {
+		outb = c.tail[len(src)]
+		if c.short[len(src)] > num {
+			outb--
+		}
+	}
+
+	for i := outb - 1; i >= 0; i-- {
+		dst[i] = c.encode[num%c.radix]
+		num /= c.radix
+	}
+	return outb
+}
+
+// encodeGroups encodes src into dst, returning the number of bytes written.
+// Any shortened last group has a stop character, unless it's WithoutStop().
+func (c *Codec) encodeGroups(dst, src []byte) int {
+	idx := 0
+
+	for len(src) >= c.bytes {
+		idx += c.encodeGroup(dst[idx:], src[:c.bytes])
+		src = src[c.bytes:]
+	}
+
+	if len(src) > 0 {
+		idx += c.encodeGroup(dst[idx:], src)
+		if !c.noStop {
+			dst[idx] = '.'
+			idx++
+		}
+	}
+
+	return idx
+}
+
+// Encode encodes src into EncodeLen(len(src)) bytes of dst, see Encode().
+func (c *Codec) Encode(dst, src []byte) []byte {
+	return dst[:c.encodeGroups(dst, src)]
+}
+
+// EncodeChecked encodes src into dst, see EncodeChecked().
+func (c *Codec) EncodeChecked(dst, src []byte) (int, error) {
+	if len(dst) < c.EncodeLen(len(src)) {
+		return 0, io.ErrShortBuffer
+	}
+
+	return c.encodeGroups(dst, src), nil
+}
+
+// EncodeToBytes returns the encoding of src.
+func (c *Codec) EncodeToBytes(src []byte) []byte {
+	return c.Encode(make([]byte, c.EncodeLen(len(src))), src)
+}
+
+// EncodeToString returns the encoding of src as a string.
+func (c *Codec) EncodeToString(src []byte) string {
+	return string(c.EncodeToBytes(src))
+}
+
+// AppendEncode appends the encoding of src to dst, see AppendEncode().
+func (c *Codec) AppendEncode(dst, src []byte) []byte {
+	dst = grow(dst, c.EncodeLen(len(src)))
+	n := len(dst)
+	return dst[:n+len(c.Encode(dst[n:cap(dst)], src))]
+}
+
+// decodeGroup decodes a single group of characters, which are all in the
+// alphabet, into dst returning the number of bytes written.
+func (c *Codec) decodeGroup(dst, grp []byte) (int, error) {
+	count := c.lens[len(grp)]
+	if count < 0 {
+		return 0, ErrNonCanonical
+	}
+
+	var num uint64
+	for _, ch := range grp {
+		hi, lo := bits.Mul64(num, c.radix)
+		var carry uint64
+		num, carry = bits.Add64(lo, uint64(c.enc.decodeMap[ch]), 0)
+		if hi != 0 || carry != 0 {
+			return 0, ErrOverflow
+		}
+	}
+	if count < 8 && num>>(8*uint(count)) != 0 {
+		if count == c.bytes {
+			return 0, ErrOverflow
+		}
+		return 0, ErrNonCanonical
+	}
+
+	if len(dst) < count {
+		return 0, io.ErrShortBuffer
+	}
+	for i := count - 1; i >= 0; i-- {
+		dst[i] = byte(num & 0xFF)
+		num >>= 8
+	}
+	return count, nil
+}
+
+// Decode decodes src into dst, returning the bytes written, see Decode().
+func (c *Codec) Decode(dst, src []byte) ([]byte, error) {
+	return c.enc.Decode(dst, src)
+}
+
+// DecodeString returns the bytes represented by the string s, see
+// DecodeString().
+func (c *Codec) DecodeString(s string) ([]byte, error) {
+	src := []byte(s)
+	return c.Decode(src, src)
+}
+
+// AppendDecode appends the decoding of src to dst, see AppendDecode().
+func (c *Codec) AppendDecode(dst, src []byte) ([]byte, error) {
+	dst = grow(dst, c.DecodeLen(len(src)))
+	n := len(dst)
+	decoded, err := c.Decode(dst[n:cap(dst)], src)
+	return dst[:n+len(decoded)], err
+}
+
+// NewEncoder returns a new stream encoder using the Codec c, see
+// NewEncoder().
+func (c *Codec) NewEncoder(w io.Writer) io.WriteCloser {
+	return c.enc.NewEncoder(w)
+}
+
+// NewDecoder constructs a new stream decoder using the Codec c, see
+// NewDecoder().
+func (c *Codec) NewDecoder(r io.Reader) io.Reader {
+	return c.enc.NewDecoder(r)
+}
+
+// NewDecoderContext constructs a new stream decoder using the Codec c, which
+// stops when ctx is done, see NewDecoderContext().
+func (c *Codec) NewDecoderContext(ctx context.Context, r io.Reader) io.Reader {
+	return c.enc.NewDecoderContext(ctx, r)
+}
@@ -0,0 +1,193 @@
+package base50
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBase50Codec(t *testing.T) {
+	var printable []byte
+	for c := byte('!'); c <= '~'; c++ {
+		if c != '.' && c != '_' {
+			printable = append(printable, c)
+		}
+	}
+
+	data := []struct {
+		alphabet     string
+		bytes, chars int
+	}{
+		{"01", 1, 8},
+		{"012", 8, 41},
+		{"0123456789", 7, 17},
+		{"0123456789abcdef", 1, 2},
+		{Alphabet, 7, 10},
+		{"0123456789abcdefghijklmnopqrstuvwxyz", 7, 11},
+		{string(printable[:62]), 8, 11},
+		{string(printable), 4, 5},
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := range data {
+		c, err := NewCodec(data[i].alphabet)
+		if err != nil {
+			t.Fatalf("bad err: %d: %v\n", i, err)
+		}
+		if b, n := c.Group(); b != data[i].bytes || n != data[i].chars {
+			t.Errorf("bad group: %d: %d/%d\n", i, b, n)
+		}
+
+		for l := 0; l <= 3*data[i].bytes; l++ {
+			for _, fill := range []int{0x00, 0x01, 0xFF, -1} {
+				val := bytes.Repeat([]byte{byte(fill)}, l)
+				if fill < 0 {
+					rnd.Read(val)
+				}
+
+				encoded := c.EncodeToBytes(val)
+				if len(encoded) > c.EncodeLen(l) || c.DecodeLen(len(encoded)) < l {
+					t.Errorf("bad len: %d: %x <%s>\n", i, val, encoded)
+				}
+				decoded, err := c.DecodeString(string(encoded))
+				if err != nil || !bytes.Equal(decoded, val) {
+					t.Errorf("bad decode: %d: %x <%s> made %x %v\n",
+						i, val, encoded, decoded, err)
+				}
+
+				var bb bytes.Buffer
+				w := c.NewEncoder(&bb)
+				for _, b := range val {
+					if _, err := w.Write([]byte{b}); err != nil {
+						t.Fatalf("write err: %v\n", err)
+					}
+				}
+				if err := w.Close(); err != nil {
+					t.Fatalf("close err: %v\n", err)
+				}
+				if bb.String() != string(encoded) {
+					t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+						i, encoded, bb.String())
+				}
+
+				r := iotest.OneByteReader(bytes.NewReader(encoded))
+				decoded, err = ioutil.ReadAll(c.NewDecoder(r))
+				if err != nil || !bytes.Equal(decoded, val) {
+					t.Errorf("bad stream decode: %d: <%s> made %v\n",
+						i, encoded, err)
+				}
+			}
+		}
+	}
+}
+
+func TestBase50CodecStd(t *testing.T) {
+	c, _ := NewCodec(Alphabet)
+
+	// Lots of data that is just on either side of the shortening.
+	val := []byte("abcdefghijklmnopqrstuvwxyz")
+	for _, v := range [][]byte{{0x00}, {0x31}, {0x32}, {0x5F, 0x5E, 0x0F},
+		{0x5F, 0x5E, 0x10}, {0xB5, 0xE6, 0x20, 0xF4, 0x7F}, {0xB5, 0xE6, 0x20, 0xF4, 0x80}} {
+		val = append(val, v...)
+	}
+
+	for l := 0; l <= len(val); l++ {
+		for off := 0; off < l && off < 12; off++ {
+			tst := EncodeToString(val[off:l])
+			if encoded := c.EncodeToString(val[off:l]); encoded != tst {
+				t.Errorf("data not equal: %d/%d\n tst=<%s>\n got <%s>\n",
+					l, off, tst, encoded)
+			}
+		}
+	}
+	for x := 0; x < 100; x++ {
+		if c.EncodeLen(x) != EncodeLen(x) || c.DecodeLen(x) != DecodeLen(x) {
+			t.Errorf("bad len: %d\n", x)
+		}
+	}
+
+	for _, tst := range []error{ErrNonCanonical, ErrOverflow,
+		ErrTruncatedGroup, ErrInvalidChar} {
+		for _, s := range []string{"56.", "zzzzzzzzzz", "1x..", "1O",
+			"H1jP5 eefyh 112 sI."} {
+			_, derr := DecodeString(s)
+			if !errors.Is(derr, tst) {
+				continue
+			}
+
+			_, err := c.DecodeString(s)
+			var e1, e2 *DecodeError
+			if !errors.Is(err, tst) || !errors.As(err, &e1) ||
+				!errors.As(derr, &e2) || e1.Offset != e2.Offset ||
+				e1.Group != e2.Group || e1.DecodedOffset != e2.DecodedOffset {
+				t.Errorf("bad err: %q made %v not %v\n", s, err, derr)
+			}
+		}
+	}
+}
+
+func TestBase50CodecBase36(t *testing.T) {
+	val := []byte("hello, world")
+	encoded := Base36.EncodeToString(val)
+	if strings.ToLower(encoded) != encoded {
+		t.Errorf("not lower case: <%s>\n", encoded)
+	}
+
+	decoded, err := Base36.DecodeString(strings.ToUpper(encoded))
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad decode: <%s> made %q %v\n", encoded, decoded, err)
+	}
+
+	// DNS labels can't have a '.' in them.
+	label := Base36.WithoutStop()
+	for l := 0; l <= len(val); l++ {
+		encoded := label.EncodeToString(val[:l])
+		if strings.ContainsRune(encoded, '.') || len(encoded) > label.EncodeLen(l) {
+			t.Errorf("bad encode: %d <%s>\n", l, encoded)
+		}
+		var bb bytes.Buffer
+		w := label.NewEncoder(&bb)
+		w.Write(val[:l])
+		w.Close()
+		if bb.String() != encoded {
+			t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+				l, encoded, bb.String())
+		}
+		decoded, err := Base36.DecodeString(strings.ToUpper(encoded))
+		if err != nil || !bytes.Equal(decoded, val[:l]) {
+			t.Errorf("bad decode: <%s> made %q %v\n", encoded, decoded, err)
+		}
+	}
+	if encoded := Base36.EncodeToString([]byte("hello")); encoded != "5pzcszu7." {
+		t.Errorf("bad encode: <%s>\n", encoded)
+	}
+	if encoded := label.EncodeToString([]byte("hello")); encoded != "5pzcszu7" {
+		t.Errorf("bad encode: <%s>\n", encoded)
+	}
+
+	// The limits, and context, are the same as an Encoding.
+	if _, err := Base36.WithMaxSize(5).DecodeString(encoded); !errors.Is(err, ErrSizeLimit) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := Base36.WithMaxSkipped(1).DecodeString(" " + encoded + " "); !errors.Is(err, ErrSkipLimit) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := Base36.NewDecoderContext(ctx, strings.NewReader(encoded))
+	if _, err := ioutil.ReadAll(r); err != context.Canceled {
+		t.Errorf("bad err: %v\n", err)
+	}
+
+	if _, err := NewCodec("0"); err == nil {
+		t.Errorf("no err: alphabet too short\n")
+	}
+	if _, err := NewCodec("00"); err == nil {
+		t.Errorf("no err: alphabet repeated\n")
+	}
+}
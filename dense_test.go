@@ -0,0 +1,128 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestBase50Dense(t *testing.T) {
+	denc := StdEncoding.Dense()
+
+	// Use lots of 0x00 and 0xFF, as those are the edge cases.
+	bvals := []byte{0x00, 0x00, 0x01, 0x7F, 0xFE, 0xFF, 0xFF}
+	rnd := rand.New(rand.NewSource(1))
+	var vals [][]byte
+	for l := 0; l <= 95; l++ {
+		for i := 0; i < 20; i++ {
+			val := make([]byte, l)
+			for j := range val {
+				val[j] = bvals[rnd.Intn(len(bvals))]
+			}
+			vals = append(vals, val)
+		}
+	}
+
+	for _, enc := range []*Encoding{denc, denc.WithGroupCheck(),
+		denc.WithCheck(), denc.FixedLength()} {
+		for _, val := range vals {
+			encoded := enc.EncodeToBytes(val)
+			if len(encoded) > enc.EncodeLen(len(val)) {
+				t.Errorf("bad len: %x: <%s>\n", val, encoded)
+			}
+			if enc.DecodeLen(len(encoded)) < len(val) {
+				t.Errorf("bad decode len: %x: <%s>\n", val, encoded)
+			}
+
+			decoded, err := enc.Strict().Decode(make([]byte, len(val)), encoded)
+			if err != nil || !bytes.Equal(decoded, val) {
+				t.Errorf("bad decode: %x: <%s> made %x %v\n",
+					val, encoded, decoded, err)
+			}
+		}
+	}
+
+	// A full group is 44 characters, and upto 6 bytes is the same as base50.
+	ff := bytes.Repeat([]byte{0xFF}, 31)
+	if encoded := denc.EncodeToString(ff); len(encoded) != 44 {
+		t.Errorf("bad full group: <%s>\n", encoded)
+	}
+	for _, val := range vals {
+		if len(val) >= 7 {
+			break
+		}
+		if denc.EncodeToString(val) != EncodeToString(val) {
+			t.Errorf("bad short group: %x\n", val)
+		}
+	}
+
+	// 31 bytes, which is 248 bits, is the most that fits in 44 characters.
+	big := strings.Repeat("z", 44)
+	if _, err := denc.DecodeString(big); !errors.Is(err, ErrOverflow) {
+		t.Errorf("bad err: <%s> made %v\n", big, err)
+	}
+}
+
+func TestBase50DenseStrict(t *testing.T) {
+	denc := StdEncoding.Dense()
+
+	// 1 byte is 1 or 2 characters, like base50.
+	if _, err := denc.Strict().DecodeString("01."); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := denc.FixedLength().DecodeString("1."); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if decoded, err := denc.DecodeString("01."); err != nil ||
+		!bytes.Equal(decoded, []byte{1}) {
+		t.Errorf("bad decode: %x %v\n", decoded, err)
+	}
+
+	// 1 byte can't be more than 255.
+	if _, err := denc.DecodeString("zz."); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
+
+func TestBase50DenseStream(t *testing.T) {
+	denc := StdEncoding.Dense()
+
+	val := make([]byte, 5000)
+	for i := range val {
+		val[i] = byte(i * 7)
+	}
+	encoded := denc.EncodeToBytes(val)
+
+	var buf bytes.Buffer
+	w := denc.NewEncoder(&buf)
+	for i := 0; i < len(val); i += 333 {
+		end := i + 333
+		if end > len(val) {
+			end = len(val)
+		}
+		if _, err := w.Write(val[i:end]); err != nil {
+			t.Fatalf("bad err: %v\n", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("bad err: %v\n", err)
+	}
+	if !bytes.Equal(buf.Bytes(), encoded) {
+		t.Errorf("stream encoding not equal\n")
+	}
+
+	decoded, err := ioutil.ReadAll(denc.NewDecoder(&buf))
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad stream decode: %v\n", err)
+	}
+
+	for _, r := range [][2]int{{0, 1}, {30, 2}, {31, 31}, {100, 1000}, {4990, 10}} {
+		decoded, err := denc.DecodeRange(encoded, r[0], r[1])
+		if err != nil || !bytes.Equal(decoded, val[r[0]:r[0]+r[1]]) {
+			t.Errorf("bad range: %v made %v\n", r, err)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package base50
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUncorrectable is for FEC encodings that have more damage than the parity
+// can correct.
+var ErrUncorrectable = errors.New("base50: too many errors to correct")
+
+// Reed-Solomon over GF(2**8), with the primitive polynomial 0x11d. See:
+// https://en.wikiversity.org/wiki/Reed%E2%80%93Solomon_codes_for_coders
+// Polynomials are []byte with the highest degree coefficient first.
+var (
+	gfExp [512]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(x, y byte) byte {
+	if x == 0 || y == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[x])+int(gfLog[y])]
+}
+
+func gfDiv(x, y byte) byte {
+	if x == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[x])+255-int(gfLog[y])]
+}
+
+func gfInv(x byte) byte {
+	return gfExp[255-int(gfLog[x])]
+}
+
+func polyScale(p []byte, x byte) []byte {
+	r := make([]byte, len(p))
+	for i := range p {
+		r[i] = gfMul(p[i], x)
+	}
+	return r
+}
+
+func polyAdd(p, q []byte) []byte {
+	n := len(p)
+	if len(q) > n {
+		n = len(q)
+	}
+	r := make([]byte, n)
+	copy(r[n-len(p):], p)
+	for i := range q {
+		r[i+n-len(q)] ^= q[i]
+	}
+	return r
+}
+
+func polyMul(p, q []byte) []byte {
+	r := make([]byte, len(p)+len(q)-1)
+	for j := range q {
+		for i := range p {
+			r[i+j] ^= gfMul(p[i], q[j])
+		}
+	}
+	return r
+}
+
+func polyEval(p []byte, x byte) byte {
+	y := p[0]
+	for i := 1; i < len(p); i++ {
+		y = gfMul(y, x) ^ p[i]
+	}
+	return y
+}
+
+func reversed(p []byte) []byte {
+	r := make([]byte, len(p))
+	for i := range p {
+		r[len(p)-1-i] = p[i]
+	}
+	return r
+}
+
+// rsParity returns the nsym bytes of parity for msg.
+func rsParity(msg []byte, nsym int) []byte {
+	gen := []byte{1}
+	for i := 0; i < nsym; i++ {
+		gen = polyMul(gen, []byte{1, gfExp[i]})
+	}
+
+	// Synthetic division of msg * x**nsym by gen, the remainder is the parity.
+	out := make([]byte, len(msg)+nsym)
+	copy(out, msg)
+	for i := range msg {
+		coef := out[i]
+		if coef == 0 {
+			continue
+		}
+		for j := 1; j < len(gen); j++ {
+			out[i+j] ^= gfMul(gen[j], coef)
+		}
+	}
+	return out[len(msg):]
+}
+
+// rsSyndromes returns the nsym syndromes of msg, after a leading 0.
+func rsSyndromes(msg []byte, nsym int) ([]byte, bool) {
+	synd := make([]byte, nsym+1)
+	bad := false
+	for i := 0; i < nsym; i++ {
+		synd[i+1] = polyEval(msg, gfExp[i])
+		if synd[i+1] != 0 {
+			bad = true
+		}
+	}
+	return synd, bad
+}
+
+// rsForneySyndromes removes the erasures at pos from the syndromes.
+func rsForneySyndromes(synd []byte, pos []int, nmsg int) []byte {
+	fsynd := append([]byte(nil), synd[1:]...)
+	for _, p := range pos {
+		x := gfExp[nmsg-1-p]
+		for j := 0; j < len(fsynd)-1; j++ {
+			fsynd[j] = gfMul(fsynd[j], x) ^ fsynd[j+1]
+		}
+	}
+	return fsynd
+}
+
+// rsErrorLocator finds the error locator polynomial with Berlekamp-Massey.
+func rsErrorLocator(synd []byte, nsym, erasures int) ([]byte, error) {
+	errLoc := []byte{1}
+	oldLoc := []byte{1}
+	for k := 0; k < nsym-erasures; k++ {
+		delta := synd[k]
+		for j := 1; j < len(errLoc); j++ {
+			delta ^= gfMul(errLoc[len(errLoc)-1-j], synd[k-j])
+		}
+		oldLoc = append(oldLoc, 0)
+		if delta != 0 {
+			if len(oldLoc) > len(errLoc) {
+				newLoc := polyScale(oldLoc, delta)
+				oldLoc = polyScale(errLoc, gfInv(delta))
+				errLoc = newLoc
+			}
+			errLoc = polyAdd(errLoc, polyScale(oldLoc, delta))
+		}
+	}
+
+	for len(errLoc) > 0 && errLoc[0] == 0 {
+		errLoc = errLoc[1:]
+	}
+	if (len(errLoc)-1)*2+erasures > nsym {
+		return nil, ErrUncorrectable
+	}
+	return errLoc, nil
+}
+
+// rsFindErrors finds the positions of the errors with a Chien search.
+func rsFindErrors(errLoc []byte, nmsg int) ([]int, error) {
+	rloc := reversed(errLoc)
+	var pos []int
+	for i := 0; i < nmsg; i++ {
+		if polyEval(rloc, gfExp[i]) == 0 {
+			pos = append(pos, nmsg-1-i)
+		}
+	}
+	if len(pos) != len(errLoc)-1 {
+		return nil, ErrUncorrectable
+	}
+	return pos, nil
+}
+
+// rsCorrectErrata fixes the errors and erasures at pos in msg, with the
+// Forney algorithm.
+func rsCorrectErrata(msg, synd []byte, pos []int) error {
+	loc := []byte{1}
+	x := make([]byte, len(pos))
+	for i, p := range pos {
+		x[i] = gfExp[len(msg)-1-p]
+		loc = polyMul(loc, []byte{x[i], 1})
+	}
+
+	prod := polyMul(reversed(synd), loc)
+	eval := prod[len(prod)-len(loc):]
+
+	for i, xi := range x {
+		xiInv := gfInv(xi)
+		prime := byte(1)
+		for j, xj := range x {
+			if j != i {
+				prime = gfMul(prime, 1^gfMul(xiInv, xj))
+			}
+		}
+		if prime == 0 {
+			return ErrUncorrectable
+		}
+		y := gfMul(xi, polyEval(eval, xiInv))
+		msg[pos[i]] ^= gfDiv(y, prime)
+	}
+	return nil
+}
+
+// rsCorrect corrects msg, which ends with nsym bytes of parity, in place.
+// Erasures are the positions of bytes known to be bad.
+func rsCorrect(msg []byte, nsym int, erasures []int) error {
+	if len(erasures) > nsym {
+		return ErrUncorrectable
+	}
+	for _, p := range erasures {
+		msg[p] = 0
+	}
+
+	synd, bad := rsSyndromes(msg, nsym)
+	if !bad {
+		return nil
+	}
+
+	fsynd := rsForneySyndromes(synd, erasures, len(msg))
+	errLoc, err := rsErrorLocator(fsynd, nsym, len(erasures))
+	if err != nil {
+		return err
+	}
+	pos, err := rsFindErrors(errLoc, len(msg))
+	if err != nil {
+		return err
+	}
+	pos = append(append([]int(nil), erasures...), pos...)
+	if err := rsCorrectErrata(msg, synd, pos); err != nil {
+		return err
+	}
+
+	if _, bad = rsSyndromes(msg, nsym); bad {
+		return ErrUncorrectable
+	}
+	return nil
+}
+
+// FEC is a forward error correction layer, which adds Reed-Solomon parity to
+// data before it is base50 encoded. The data is split into blocks of upto 255
+// bytes, including the parity, and the blocks are interleaved so that
+// the 7 bytes of a damaged group are spread over different blocks.
+//
+// When decoding, a group which fails to decode (Eg. it has a character that
+// isn't in the alphabet of a Lenient() encoding, or it fails the check of a
+// WithGroupCheck() encoding) is a known erasure. Each block can correct upto
+// parity erasures, or half that many unknown errors. Damaged characters should
+// be replaced with something like '?' so the groups stay aligned.
+type FEC struct {
+	enc    *Encoding
+	parity int
+}
+
+// NewFEC returns a FEC using the encoding enc, with parity bytes of parity
+// per block, which must be 1 to 254. enc can't be a WithCheck() encoding, use
+// WithGroupCheck() instead. enc also can't be an Ordered() encoding, as the
+// shortened last group is the same length as a full group so the number of
+// bytes in a damaged group isn't known.
+func NewFEC(enc *Encoding, parity int) (*FEC, error) {
+	if parity < 1 || parity > 254 {
+		return nil, fmt.Errorf("base50: FEC parity %d not in 1 to 254", parity)
+	}
+	if enc.check {
+		return nil, errors.New("base50: FEC can't use WithCheck() encodings")
+	}
+	if enc.ordered {
+		return nil, errors.New("base50: FEC can't use Ordered() encodings")
+	}
+	return &FEC{enc: enc, parity: parity}, nil
+}
+
+// blocks returns the number of blocks needed for total bytes of data and
+// parity, and the size of the first block (others can be one byte smaller).
+func (f *FEC) blocks(total int) (int, int) {
+	b := (total + 254) / 255
+	if b == 0 {
+		return 0, 0
+	}
+	return b, (total + b - 1) / b
+}
+
+// blockLen returns the length of block i, of b blocks, for total bytes.
+func blockLen(total, b, i int) int {
+	n := total / b
+	if i < total%b {
+		n++
+	}
+	return n
+}
+
+// EncodeToBytes returns the base50 encoding of src, with Reed-Solomon parity.
+func (f *FEC) EncodeToBytes(src []byte) []byte {
+	if len(src) == 0 {
+		return nil
+	}
+
+	b := (len(src) + (255 - f.parity) - 1) / (255 - f.parity)
+	total := len(src) + b*f.parity
+	buf := make([]byte, total)
+
+	// Block i is at buf[i], buf[i+b], buf[i+2*b] ...
+	for i := 0; i < b; i++ {
+		n := blockLen(total, b, i) - f.parity
+		blk := append(src[:n:n], rsParity(src[:n], f.parity)...)
+		for j, c := range blk {
+			buf[i+j*b] = c
+		}
+		src = src[n:]
+	}
+
+	return f.enc.EncodeToBytes(buf)
+}
+
+// EncodeToString returns the base50 encoding of src, with Reed-Solomon parity,
+// as a string.
+func (f *FEC) EncodeToString(src []byte) string {
+	return string(f.EncodeToBytes(src))
+}
+
+// groups decodes the groups in src, returning the bytes and the offsets of
+// the bytes from groups which failed to decode.
+func (f *FEC) groups(src []byte) ([]byte, []int) {
+	chars := make([]byte, 0, len(src))
+	for _, c := range src {
+		if f.enc.decodeMap[c] != skipClass {
+			chars = append(chars, c)
+		}
+	}
+	if len(chars) > 0 && chars[len(chars)-1] == '.' {
+		chars = chars[:len(chars)-1]
+	}
+
+	glen := f.enc.groupLen()
+	out := make([]byte, 0, f.enc.DecodeLen(len(chars)))
+	var erasures []int
+	for len(chars) > 0 {
+		grp := chars
+		if len(grp) > glen {
+			grp = grp[:glen]
+		}
+		chars = chars[len(grp):]
+
+		ok := true
+		if f.enc.groupCheck && len(grp) < 2 {
+			break
+		}
+		if f.enc.groupCheck {
+			chk := grp[len(grp)-1]
+			grp = grp[:len(grp)-1]
+			for _, c := range grp {
+				ok = ok && f.enc.decodeMap[c] < stopClass
+			}
+			ok = ok && f.enc.decodeMap[chk] < stopClass &&
+				f.enc.checkChar(grp) == f.enc.encode[f.enc.decodeMap[chk]]
+		}
+
+		n := f.enc.DecodeLen(len(grp))
+		dst := out[len(out) : len(out)+n]
+		if ok {
+			if _, err := f.enc.decodeGroup(dst, grp); err != nil {
+				ok = false
+			}
+		}
+		if !ok {
+			for i := range dst {
+				dst[i] = 0
+				erasures = append(erasures, len(out)+i)
+			}
+		}
+		out = out[:len(out)+n]
+	}
+
+	return out, erasures
+}
+
+// Decode returns the data from the base50 encoding src, correcting errors with
+// the Reed-Solomon parity. If there is too much damage the error is
+// ErrUncorrectable.
+func (f *FEC) Decode(src []byte) ([]byte, error) {
+	buf, erasures := f.groups(src)
+	total := len(buf)
+	b, blen := f.blocks(total)
+	if b > 0 && blockLen(total, b, b-1) <= f.parity {
+		return nil, ErrUncorrectable
+	}
+
+	ret := make([]byte, 0, total-b*f.parity)
+	blk := make([]byte, blen)
+	var bera []int
+	for i := 0; i < b; i++ {
+		blk = blk[:blockLen(total, b, i)]
+		for j := range blk {
+			blk[j] = buf[i+j*b]
+		}
+		bera = bera[:0]
+		for _, e := range erasures {
+			if e%b == i {
+				bera = append(bera, e/b)
+			}
+		}
+
+		if err := rsCorrect(blk, f.parity, bera); err != nil {
+			return nil, err
+		}
+		ret = append(ret, blk[:len(blk)-f.parity]...)
+	}
+
+	return ret, nil
+}
+
+// DecodeString returns the data from the base50 encoding s, see Decode().
+func (f *FEC) DecodeString(s string) ([]byte, error) {
+	return f.Decode([]byte(s))
+}
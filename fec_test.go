@@ -0,0 +1,115 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBase50FEC(t *testing.T) {
+	if _, err := NewFEC(StdEncoding, 0); err == nil {
+		t.Errorf("no err for parity 0\n")
+	}
+	if _, err := NewFEC(StdEncoding, 255); err == nil {
+		t.Errorf("no err for parity 255\n")
+	}
+	if _, err := NewFEC(StdEncoding.WithCheck(), 8); err == nil {
+		t.Errorf("no err for WithCheck()\n")
+	}
+	if _, err := NewFEC(StdEncoding.Ordered(), 8); err == nil {
+		t.Errorf("no err for Ordered()\n")
+	}
+
+	f, err := NewFEC(StdEncoding, 8)
+	if err != nil {
+		t.Fatalf("bad err: %v\n", err)
+	}
+
+	val := make([]byte, 1000)
+	for i := range val {
+		val[i] = byte(i * 7)
+	}
+	for _, l := range []int{0, 1, 6, 7, 8, 100, 246, 247, 248, 500, 1000} {
+		encoded := f.EncodeToString(val[:l])
+		decoded, err := f.DecodeString(encoded)
+		if err != nil || !bytes.Equal(decoded, val[:l]) {
+			t.Errorf("bad decode: %d: <%s> made %v\n", l, encoded, err)
+		}
+	}
+}
+
+func TestBase50FECErasures(t *testing.T) {
+	val := []byte("The quick brown fox jumps over the lazy dog, again and again.")
+
+	for _, enc := range []*Encoding{StdEncoding.Lenient(),
+		StdEncoding.WithGroupCheck()} {
+		f, _ := NewFEC(enc, 8)
+		encoded := f.EncodeToBytes(val)
+		glen := enc.groupLen()
+
+		// One damaged group is 7 erasures, which 8 parity can fix. This is
+		// a single block so it's the same as 7 bytes of damage.
+		bad := append([]byte(nil), encoded...)
+		for i := 0; i < glen; i++ {
+			bad[glen+i] = '?'
+		}
+		decoded, err := f.Decode(bad)
+		if err != nil || !bytes.Equal(decoded, val) {
+			t.Errorf("bad decode: <%s> made %q %v\n", bad, decoded, err)
+		}
+
+		// Two damaged groups is too much.
+		for i := 0; i < glen; i++ {
+			bad[3*glen+i] = '?'
+		}
+		if _, err := f.Decode(bad); !errors.Is(err, ErrUncorrectable) {
+			t.Errorf("bad err: <%s> made %v\n", bad, err)
+		}
+	}
+
+	// Look-alike characters are read as the character they look like.
+	f, _ := NewFEC(StdEncoding.Lenient(), 8)
+	encoded := f.EncodeToBytes(val)
+	bad := bytes.Replace(encoded, []byte("0"), []byte("O"), -1)
+	decoded, err := f.Decode(bad)
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad decode: <%s> made %v\n", bad, err)
+	}
+}
+
+func TestBase50FECErrors(t *testing.T) {
+	val := make([]byte, 600)
+	for i := range val {
+		val[i] = byte(i * 13)
+	}
+
+	// 600 bytes, with 16 parity, is 3 interleaved blocks.
+	f, _ := NewFEC(StdEncoding, 16)
+	encoded := f.EncodeToBytes(val)
+
+	// Changing a character is an unknown error, in upto 7 bytes over
+	// different blocks. So 8 errors per block can be corrected.
+	bad := append([]byte(nil), encoded...)
+	for i := 0; i < 3; i++ {
+		c := bad[i*100+5]
+		bad[i*100+5] = Alphabet[(StdEncoding.decodeMap[c]+1)%50]
+	}
+	decoded, err := f.Decode(bad)
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad decode: made %v\n", err)
+	}
+
+	// Whitespace and underbars are skipped.
+	spaced := bytes.Replace(encoded, []byte("0"), []byte(" _0\n"), -1)
+	decoded, err = f.Decode(spaced)
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad decode: made %v\n", err)
+	}
+
+	for i := 100; i < 400; i++ {
+		bad[i] = '1'
+	}
+	if _, err := f.Decode(bad); !errors.Is(err, ErrUncorrectable) {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
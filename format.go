@@ -0,0 +1,183 @@
+package base50
+
+import (
+	"fmt"
+)
+
+// DefaultLineWrap is the most characters in each line for WithLineWrap(0).
+// It's rounded down to a whole number of groups, so lines end at a group. Eg.
+// 70 for base50, 66 for WithGroupCheck() and 44 for Dense().
+const DefaultLineWrap = 70
+
+// WithLineWrap returns a copy of enc which puts a newline after every n
+// encoded characters, so the output can be printed or put in an email. If
+// n <= 0 it's DefaultLineWrap. The newlines are skipped when decoding, like
+// all whitespace. There's no newline at the end of the output, or before a
+// stop character.
+func (enc *Encoding) WithLineWrap(n int) *Encoding {
+	if n <= 0 {
+		n = -1 // See lineWrap(), the group length can still change
+	}
+	e := *enc
+	e.wrap = n
+	return &e
+}
+
+// lineWrap returns the number of characters in a line, or 0 for no newlines.
+func (enc *Encoding) lineWrap() int {
+	if enc.wrap >= 0 {
+		return enc.wrap
+	}
+	glen := enc.groupLen()
+	if DefaultLineWrap < glen {
+		return glen
+	}
+	return DefaultLineWrap / glen * glen
+}
+
+// SeparatorError values describe why a separator given to WithSeparator()
+// can't be used.
+type SeparatorError string
+
+func (e SeparatorError) Error() string {
+	return "base50: invalid separator: " + string(e)
+}
+
+// WithSeparator returns a copy of enc which puts the separator sep after
+// every k encoded characters, to make the output easier to read. Eg. with
+// '_' and 5 "rwdnuFSFPF" is "rwdnu_FSFPF". Like WithLineWrap() there's no
+// separator at the end, before a stop character or at the end of a line. If
+// k <= 0 there's no separator.
+//
+// The separator must be '_', ' ' or '-', and '-' can't be in the alphabet, or
+// it returns a SeparatorError. The encoding skips the separator when
+// decoding, so '-' is skipped like whitespace and underbars (but not for
+// Strict() decoding).
+func (enc *Encoding) WithSeparator(sep byte, k int) (*Encoding, error) {
+	e := *enc
+	switch {
+	case k <= 0:
+		e.sep, e.every = 0, 0
+		return &e, nil
+	case sep == '-' && e.decodeMap[sep] < stopClass:
+		return nil, SeparatorError(fmt.Sprintf("%q is in the alphabet", sep))
+	case sep == '-':
+		e.decodeMap[sep] = skipClass
+	case sep != '_' && sep != ' ':
+		return nil, SeparatorError(fmt.Sprintf("%q isn't '_', ' ' or '-'", sep))
+	}
+
+	e.sep, e.every = sep, k
+	return &e, nil
+}
+
+// formatted returns true if enc adds newlines or separators to the output.
+func (enc *Encoding) formatted() bool {
+	return enc.wrap != 0 || enc.every > 0
+}
+
+// separator returns the newline or separator to put before the encoded
+// character c, which is at position pos of the output (not counting the
+// newlines and separators), or 0 for nothing.
+func (enc *Encoding) separator(pos int64, c byte) byte {
+	wrap := enc.lineWrap()
+	switch {
+	case pos == 0 || c == '.':
+		return 0
+	case wrap > 0 && pos%int64(wrap) == 0:
+		return '\n'
+	case enc.every > 0 && pos%int64(enc.every) == 0:
+		return enc.sep
+	}
+	return 0
+}
+
+// formatExtra returns the most newlines and separators added to n encoded
+// characters.
+func (enc *Encoding) formatExtra(n int) int {
+	if n <= 1 || !enc.formatted() {
+		return 0
+	}
+
+	extra := 0
+	wrap := enc.lineWrap()
+	if wrap > 0 {
+		extra += (n - 1) / wrap
+	}
+	if enc.every > 0 {
+		extra += (n - 1) / enc.every
+		if wrap > 0 { // Newlines replace separators
+			extra -= (n - 1) / lcm(wrap, enc.every)
+		}
+	}
+	return extra
+}
+
+func lcm(a, b int) int {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return a / x * b
+}
+
+// format adds the newlines and separators to the n encoded characters at the
+// start of buf, which must have room for them, when the first character is
+// at position pos of the output. It returns the new length.
+func (enc *Encoding) format(buf []byte, n int, pos int64) int {
+	if !enc.formatted() {
+		return n
+	}
+
+	extra := 0
+	for i, c := range buf[:n] {
+		if enc.separator(pos+int64(i), c) != 0 {
+			extra++
+		}
+	}
+
+	// Move the characters from the end, so it can be done in place.
+	j := n + extra
+	for i := n - 1; i >= 0; i-- {
+		j--
+		buf[j] = buf[i]
+		if s := enc.separator(pos+int64(i), buf[j]); s != 0 {
+			j--
+			buf[j] = s
+		}
+	}
+	return n + extra
+}
+
+// strictSkip handles the skipped character c, at offset off in the input, for
+// Strict() decoding. It can only be a newline or separator, which is checked
+// against the next character by strictFormat().
+func (g *groupDecoder) strictSkip(c byte, off int) error {
+	if !g.enc.formatted() || g.sepC != 0 {
+		return g.error(off, InvalidByteError(c))
+	}
+	g.sepC, g.sepOff = c, off
+	return nil
+}
+
+// strictFormat checks that the newline or separator before the character c,
+// at offset off in the input, is exactly what format() puts there for
+// Strict() decoding.
+func (g *groupDecoder) strictFormat(c byte, off int) error {
+	sep, sepOff := g.sepC, g.sepOff
+	g.sepC = 0
+	if !g.enc.formatted() {
+		return nil
+	}
+
+	switch want := g.enc.separator(g.pos, c); {
+	case sep != want && sep != 0:
+		return g.error(sepOff, InvalidByteError(sep))
+	case sep != want:
+		return g.error(off, ErrNonCanonical)
+	}
+	if c != '.' {
+		g.pos++
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBase50Format(t *testing.T) {
+	data := []struct {
+		enc *Encoding
+		val string
+		out string
+	}{
+		{withSeparator(StdEncoding, '_', 5), "a", "1x."},
+		{withSeparator(StdEncoding, '_', 5), "abcdefg", "H1jP5_eefyh"},
+		{withSeparator(StdEncoding, ' ', 5), "abcdefgh", "H1jP5 eefyh 24."},
+		{withSeparator(StdEncoding, '-', 10), "abcdefghijklmn", "H1jP5eefyh-J2MJPmq0Rt"},
+		{StdEncoding.WithLineWrap(10), "abcdefghijklmn", "H1jP5eefyh\nJ2MJPmq0Rt"},
+		{withSeparator(StdEncoding.WithLineWrap(10), '_', 5), "abcdefghijklmnop",
+			"H1jP5_eefyh\nJ2MJP_mq0Rt\nEPZ."},
+		{withSeparator(StdEncoding, '_', 2), "a", "1x."},
+		{withSeparator(StdEncoding, '_', 2), "", ""},
+		{withSeparator(StdEncoding, '_', 0), "abcdefg", "H1jP5eefyh"},
+	}
+	for i := range data {
+		encoded := data[i].enc.EncodeToString([]byte(data[i].val))
+		if encoded != data[i].out {
+			t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+				i, data[i].out, encoded)
+		}
+		decoded, err := data[i].enc.DecodeString(encoded)
+		if err != nil || string(decoded) != data[i].val {
+			t.Errorf("bad decode: %d <%s> made %v\n", i, encoded, err)
+		}
+	}
+
+	val := make([]byte, 1000)
+	for i := range val {
+		val[i] = byte(i * 7)
+	}
+	for _, enc := range []*Encoding{StdEncoding.WithLineWrap(0),
+		withSeparator(StdEncoding.WithLineWrap(0), '_', 5),
+		withSeparator(StdEncoding.WithCheck().WithLineWrap(64), '-', 4),
+		withSeparator(StdEncoding.WithGroupCheck().WithLineWrap(77), ' ', 3),
+		withSeparator(StdEncoding.Dense(), '_', 11)} {
+		for l := 0; l <= len(val); l += 37 {
+			encoded := enc.EncodeToBytes(val[:l])
+			if len(encoded) > enc.EncodeLen(l) {
+				t.Errorf("bad len: %d <%s>\n", l, encoded)
+			}
+			if tst := testFormat(enc, val[:l]); !bytes.Equal(encoded, tst) {
+				t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+					l, tst, encoded)
+			}
+			lines := bytes.Split(encoded, []byte("\n"))
+			for i, line := range lines {
+				n := len(bytes.Replace(line, []byte{enc.sep}, nil, -1))
+				if i == len(lines)-1 && bytes.HasSuffix(line, []byte(".")) {
+					n-- // The stop character can be after the last newline
+				}
+				if w := enc.lineWrap(); w > 0 && (n > w || (i < len(lines)-1 && n != w)) {
+					t.Errorf("bad line: %d <%s>\n", l, line)
+				}
+			}
+			if bytes.HasSuffix(encoded, []byte{enc.sep, '.'}) ||
+				bytes.HasSuffix(encoded, []byte("\n.")) ||
+				bytes.HasSuffix(encoded, []byte("\n")) {
+				t.Errorf("bad end: %d <%s>\n", l, encoded)
+			}
+
+			if !enc.IsCanonical(encoded) {
+				t.Errorf("not canonical: %d <%s>\n", l, encoded)
+			}
+			if c, err := enc.Canonicalize(encoded); err != nil ||
+				!bytes.Equal(c, encoded) || !enc.IsCanonical(c) {
+				t.Errorf("bad canonical: %d <%s> made <%s> %v\n", l, encoded, c, err)
+			}
+
+			decoded, err := enc.Decode(make([]byte, l), encoded)
+			if err != nil || !bytes.Equal(decoded, val[:l]) {
+				t.Errorf("bad decode: %d <%s> made %v\n", l, encoded, err)
+			}
+
+			// The streaming encoder is the same.
+			var buf bytes.Buffer
+			w := enc.NewEncoder(&buf)
+			for i := 0; i < l; i += 13 {
+				end := i + 13
+				if end > l {
+					end = l
+				}
+				w.Write(val[i:end])
+			}
+			w.Close()
+			if !bytes.Equal(buf.Bytes(), encoded) {
+				t.Errorf("stream not equal: %d\n tst=<%s>\n got <%s>\n",
+					l, encoded, buf.Bytes())
+			}
+		}
+	}
+
+	// Lines are a whole number of groups, Eg. 70 characters is 7 groups.
+	for _, tst := range []struct {
+		enc  *Encoding
+		wrap int
+	}{
+		{StdEncoding.WithLineWrap(0), 70},
+		{StdEncoding.WithLineWrap(0).WithGroupCheck(), 66},
+		{StdEncoding.WithGroupCheck().WithLineWrap(0), 66},
+		{StdEncoding.WithLineWrap(0).Dense(), 44},
+		{StdEncoding.Dense().WithGroupCheck().WithLineWrap(0), 45},
+	} {
+		encoded := tst.enc.EncodeToString(val)
+		if i := strings.IndexByte(encoded, '\n'); i != tst.wrap ||
+			tst.enc.lineWrap() != tst.wrap {
+			t.Errorf("bad line length: %d not %d\n", i, tst.wrap)
+		}
+	}
+
+	// Strict() decoding only allows the newlines and separators where
+	// Encode() puts them.
+	enc := withSeparator(StdEncoding.WithLineWrap(10), '_', 5)
+	if _, err := enc.Strict().DecodeString("H1jP5_eefyh\nJ2MJP_mq0Rt\nEPZ."); err != nil {
+		t.Errorf("bad err: %v\n", err)
+	}
+	for _, tst := range []struct {
+		src string
+		err error
+		off int
+	}{
+		{"H1jP5_eefyh\nJ2MJP_mq0Rt\nEPZ.\n", ErrStopChar, 28},
+		{"H1jP5_eefyh\nJ2MJP_mq0Rt\n\nEPZ.", ErrInvalidChar, 24},
+		{"H1jP5_eefyh\nJ2MJP_mq0RtEPZ.", ErrNonCanonical, 23},
+		{"H1jP5_eefyh\nJ2MJPmq0Rt\nEPZ.", ErrNonCanonical, 17},
+		{"H1jP_5eefyh\nJ2MJP_mq0Rt\nEPZ.", ErrInvalidChar, 4},
+		{"H1jP5 eefyh\nJ2MJP_mq0Rt\nEPZ.", ErrInvalidChar, 5},
+		{"H1jP5_eefyh_J2MJP_mq0Rt\nEPZ.", ErrInvalidChar, 11},
+		{"H1jP5_eefyh\nJ2MJP_mq0Rt\nEPZ\n.", ErrInvalidChar, 27},
+		{"\nH1jP5_eefyh\nJ2MJP_mq0Rt\nEPZ.", ErrInvalidChar, 0},
+	} {
+		_, err := enc.Strict().DecodeString(tst.src)
+		var derr *DecodeError
+		if !errors.Is(err, tst.err) || !errors.As(err, &derr) ||
+			derr.Offset != tst.off {
+			t.Errorf("bad err: %q made %v\n", tst.src, err)
+		}
+		if enc.IsCanonical([]byte(tst.src)) {
+			t.Errorf("canonical: %q\n", tst.src)
+		}
+	}
+
+	// Only the encoding with the separator skips '-'.
+	dashed := withSeparator(StdEncoding, '-', 5).EncodeToString(val)
+	if _, err := StdEncoding.DecodeString(dashed); err == nil {
+		t.Errorf("no err: <%s>\n", dashed)
+	}
+}
+
+func TestBase50SeparatorErrors(t *testing.T) {
+	alt := mustNewEncoding("-" + Alphabet[1:])
+	for _, tst := range []struct {
+		enc *Encoding
+		sep byte
+	}{
+		{StdEncoding, 'x'},
+		{StdEncoding, '.'},
+		{StdEncoding, '\n'},
+		{alt, '-'},
+	} {
+		e, err := tst.enc.WithSeparator(tst.sep, 5)
+		var serr SeparatorError
+		if e != nil || !errors.As(err, &serr) {
+			t.Errorf("bad err: %q made %v\n", tst.sep, err)
+		}
+	}
+
+	// No separator is always fine.
+	if _, err := StdEncoding.WithSeparator('x', 0); err != nil {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
+
+// withSeparator returns WithSeparator() for a separator that's valid.
+func withSeparator(enc *Encoding, sep byte, k int) *Encoding {
+	e, err := enc.WithSeparator(sep, k)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// testFormat returns the encoding of src, formatted one character at a time.
+func testFormat(enc *Encoding, src []byte) []byte {
+	plain := *enc
+	plain.wrap, plain.every = 0, 0
+
+	var ret []byte
+	for i, c := range plain.EncodeToBytes(src) {
+		switch {
+		case i == 0 || c == '.':
+		case enc.lineWrap() > 0 && i%enc.lineWrap() == 0:
+			ret = append(ret, '\n')
+		case enc.every > 0 && i%enc.every == 0:
+			ret = append(ret, enc.sep)
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
@@ -0,0 +1,67 @@
+package base50
+
+// homoglyphs maps the characters left out of Alphabet to the Alphabet
+// character they look like, see the documentation on Alphabet.
+var homoglyphs = [...]struct{ from, to byte }{
+	{'B', '8'},
+	{'D', '0'},
+	{'I', '1'},
+	{'O', '0'},
+	{'i', '1'},
+	{'l', '1'},
+
+	{'C', '0'}, // C ~ O ~ 0
+	{'Q', '0'},
+	{'V', 'U'},
+	{'c', '0'}, // c ~ o ~ 0
+	{'o', '0'},
+	{'v', 'u'},
+}
+
+// Lenient returns a copy of enc which decodes the characters left out of the
+// alphabet, because they look like others, as the character they look like.
+// Eg. "O" decodes as "0" and "l" decodes as "1". This is much like Crockford's
+// base32, and is useful for human typed input. Characters which are in the
+// alphabet of enc, or which look like characters that aren't, are unchanged.
+// Use Substitutions() to find out what was changed. This can't be used with
+// Strict().
+func (enc *Encoding) Lenient() *Encoding {
+	e := *enc
+	e.strict = false
+
+	for _, h := range homoglyphs {
+		if e.decodeMap[h.from] != invalidChar {
+			continue
+		}
+		if e.decodeMap[h.to] == invalidChar || e.subst[h.to] != 0 {
+			continue
+		}
+		e.decodeMap[h.from] = e.decodeMap[h.to]
+		e.subst[h.from] = h.to
+	}
+
+	return &e
+}
+
+// A Substitution is a character in the input which a Lenient() encoding
+// decoded as a different character.
+type Substitution struct {
+	Offset int  // Offset in the input
+	From   byte // Character in the input
+	To     byte // Alphabet character it was decoded as
+}
+
+// Substitutions returns the characters in src which enc decodes as another
+// character, so a user can be warned about them. It returns nil if there are
+// none, which is always true unless enc is from Lenient().
+func (enc *Encoding) Substitutions(src []byte) []Substitution {
+	var ret []Substitution
+
+	for i, c := range src {
+		if enc.subst[c] != 0 {
+			ret = append(ret, Substitution{Offset: i, From: c, To: enc.subst[c]})
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,94 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBase50Lenient(t *testing.T) {
+	lenc := StdEncoding.Lenient()
+
+	data := []struct {
+		val string
+		tst string
+	}{
+		{"H1jP5eefyh", "H1jP5eefyh"},
+		{"HIjP5eefyh", "H1jP5eefyh"},
+		{"HljP5eefyh", "H1jP5eefyh"},
+		{"O7AHNwGgG6", "07AHNwGgG6"},
+		{"o7AHNwGgG6", "07AHNwGgG6"},
+		{"Q7AHNwGgG6", "07AHNwGgG6"},
+		{"C7AHNwGgG6", "07AHNwGgG6"},
+		{"c7AHNwGgG6", "07AHNwGgG6"},
+		{"D7AHNwGgG6", "07AHNwGgG6"},
+		{"jtfj0w3RBh", "jtfj0w3R8h"},
+		{"5EPh8V.", "5EPh8U."},
+		{"XmS03Sv.", "XmS03Su."},
+		{"112sa.", "112sa."},
+		{"i12sa.", "112sa."},
+	}
+
+	for i := range data {
+		tst, err := DecodeString(data[i].tst)
+		if err != nil {
+			t.Fatalf("bad err: %d: %v\n", i, err)
+		}
+
+		decoded, err := lenc.DecodeString(data[i].val)
+		if err != nil {
+			t.Errorf("bad err: %d: %v made %v\n", i, data[i].val, err)
+		}
+		if !bytes.Equal(decoded, tst) {
+			t.Errorf("decoded not equal: %d: %v\n tst=<%x>\n got <%x>\n",
+				i, data[i].val, tst, decoded)
+		}
+
+		subs := lenc.Substitutions([]byte(data[i].val))
+		if (data[i].val == data[i].tst) != (len(subs) == 0) {
+			t.Errorf("bad subs: %d: %v made %v\n", i, data[i].val, subs)
+		}
+		for _, s := range subs {
+			if data[i].val[s.Offset] != s.From || data[i].tst[s.Offset] != s.To {
+				t.Errorf("bad sub: %d: %v made %v\n", i, data[i].val, s)
+			}
+		}
+
+		// Strict decoding doesn't allow them.
+		_, err = DecodeString(data[i].val)
+		if (data[i].val == data[i].tst) != (err == nil) {
+			t.Errorf("bad err: %d: %v made %v\n", i, data[i].val, err)
+		}
+	}
+
+	if _, err := lenc.DecodeString("1x!"); !errors.Is(err, ErrInvalidChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if subs := StdEncoding.Substitutions([]byte("OIl")); subs != nil {
+		t.Errorf("bad subs: %v\n", subs)
+	}
+	if StdEncoding.decodeMap['O'] != invalidChar {
+		t.Errorf("Lenient() changed StdEncoding\n")
+	}
+}
+
+func TestBase50LenientAlphabet(t *testing.T) {
+	// "O" is in the alphabet instead of "0", so it can't be substituted and
+	// "D" can't be substituted for "0" either.
+	enc, err := NewEncoding("O" + Alphabet[1:])
+	if err != nil {
+		t.Fatalf("bad err: %v\n", err)
+	}
+	lenc := enc.Lenient()
+
+	if subs := lenc.Substitutions([]byte("ODIl")); len(subs) != 2 {
+		t.Errorf("bad subs: %v\n", subs)
+	}
+	decoded, err := lenc.DecodeString("OOOOOOOOOl")
+	if err != nil || !bytes.Equal(decoded, []byte{0, 0, 0, 0, 0, 0, 1}) {
+		t.Errorf("bad decode: %x %v\n", decoded, err)
+	}
+	if _, err := lenc.DecodeString("D"); !errors.Is(err, ErrInvalidChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
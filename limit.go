@@ -0,0 +1,69 @@
+package base50
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors which a LimitError wraps, to be used with errors.Is().
+var (
+	// ErrSizeLimit is for decoding more bytes than WithMaxSize().
+	ErrSizeLimit = errors.New("base50: decoded size limit exceeded")
+	// ErrSkipLimit is for skipping more characters than WithMaxSkipped().
+	ErrSkipLimit = errors.New("base50: skipped characters limit exceeded")
+)
+
+// LimitError values describe a limit that decoding went over, they are the
+// Err of a DecodeError.
+type LimitError struct {
+	Err   error // ErrSizeLimit or ErrSkipLimit
+	Limit int64 // The limit that was exceeded
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (limit %d)", e.Err, e.Limit)
+}
+
+// Unwrap returns the underlying error.
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// WithMaxSize returns a copy of enc where decoding more than n bytes is an
+// error (ErrSizeLimit), so untrusted input can't use lots of memory. This is
+// over the whole input for the streaming decoders, or all the messages for
+// DecodeSegments(). If n <= 0 there is no limit, which is the default.
+func (enc *Encoding) WithMaxSize(n int64) *Encoding {
+	e := *enc
+	e.maxSize = n
+	return &e
+}
+
+// WithMaxSkipped returns a copy of enc where skipping more than n whitespace
+// or underbar characters, while decoding, is an error (ErrSkipLimit). So
+// untrusted input can't be mostly padding. If n <= 0 there is no limit,
+// which is the default.
+func (enc *Encoding) WithMaxSkipped(n int64) *Encoding {
+	e := *enc
+	e.maxSkipped = n
+	return &e
+}
+
+// limitSize returns an error at offset off, if decoding n more bytes goes
+// over the WithMaxSize() limit.
+func (g *groupDecoder) limitSize(off, n int) error {
+	if max := g.enc.maxSize; max > 0 && int64(g.count+n) > max {
+		return g.error(off, &LimitError{Err: ErrSizeLimit, Limit: max})
+	}
+	return nil
+}
+
+// limitSkipped counts a skipped character at offset off, and returns an error
+// if that goes over the WithMaxSkipped() limit.
+func (g *groupDecoder) limitSkipped(off int) error {
+	g.skipped++
+	if max := g.enc.maxSkipped; max > 0 && g.skipped > max {
+		return g.error(off, &LimitError{Err: ErrSkipLimit, Limit: max})
+	}
+	return nil
+}
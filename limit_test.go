@@ -0,0 +1,119 @@
+package base50
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBase50Limits(t *testing.T) {
+	val := []byte("abcdefghijklmnopqrstuvwxyz")
+
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithGroupCheck(),
+		StdEncoding.Dense()} {
+		encoded := enc.EncodeToBytes(val)
+
+		for _, max := range []int64{0, 26, 100} {
+			lenc := enc.WithMaxSize(max)
+			decoded, err := lenc.Decode(make([]byte, len(val)), encoded)
+			if err != nil || !bytes.Equal(decoded, val) {
+				t.Errorf("bad decode: %d made %v\n", max, err)
+			}
+		}
+
+		lenc := enc.WithMaxSize(25)
+		_, err := lenc.Decode(make([]byte, len(val)), encoded)
+		var lerr *LimitError
+		if !errors.Is(err, ErrSizeLimit) || !errors.As(err, &lerr) ||
+			lerr.Limit != 25 {
+			t.Errorf("bad err: %v\n", err)
+		}
+
+		// The streaming decoder limits the whole input.
+		r := lenc.NewDecoder(bytes.NewReader(bytes.Repeat(encoded, 2)))
+		if _, err := ioutil.ReadAll(r); !errors.Is(err, ErrSizeLimit) {
+			t.Errorf("bad err: %v\n", err)
+		}
+	}
+
+	spaced := []byte(" 1x .")
+	enc := StdEncoding.WithMaxSkipped(2)
+	if _, err := enc.DecodeString(string(spaced)); err != nil {
+		t.Errorf("bad err: %v\n", err)
+	}
+	spaced = []byte(" 1x \n.")
+	_, err := enc.Decode(make([]byte, 1), spaced)
+	var derr *DecodeError
+	if !errors.Is(err, ErrSkipLimit) || !errors.As(err, &derr) ||
+		derr.Offset != 4 {
+		t.Errorf("bad err: %v\n", err)
+	}
+
+	// Megabytes of whitespace stop early.
+	padded := strings.Repeat(" ", 4<<20) + "1x."
+	r := enc.NewDecoder(strings.NewReader(padded))
+	if _, err := ioutil.ReadAll(r); !errors.Is(err, ErrSkipLimit) {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
+
+func TestBase50Context(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	val := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz"), 20000)
+	encoded := EncodeToBytes(val)
+
+	r := NewDecoderContext(context.Background(), bytes.NewReader(encoded))
+	decoded, err := ioutil.ReadAll(r)
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad decode: %v\n", err)
+	}
+	decoded, err = DecodeParallelContext(context.Background(),
+		make([]byte, len(val)), encoded)
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad decode: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r = NewDecoderContext(ctx, bytes.NewReader(encoded))
+	if _, err := ioutil.ReadAll(r); err != context.Canceled {
+		t.Errorf("bad err: %v\n", err)
+	}
+	for _, l := range []int{10, len(encoded)} {
+		_, err := DecodeParallelContext(ctx, make([]byte, len(val)), encoded[:l])
+		if err != context.Canceled {
+			t.Errorf("bad err: %d made %v\n", l, err)
+		}
+	}
+
+	// The limits work in parallel too.
+	_, err = StdEncoding.WithMaxSize(int64(len(val)/2)).DecodeParallel(
+		make([]byte, len(val)), encoded)
+	if !errors.Is(err, ErrSizeLimit) {
+		t.Errorf("bad err: %v\n", err)
+	}
+
+	// The skipped characters are counted over all the shards.
+	spaced := bytes.Replace(encoded, []byte("0"), []byte("0 "), -1)
+	skips := int64(bytes.Count(spaced, []byte(" ")))
+	for _, max := range []int64{skips / 2, skips - 1, skips} {
+		enc := StdEncoding.WithMaxSkipped(max)
+		dst := make([]byte, len(val))
+		tst, terr := enc.Decode(dst, spaced)
+		decoded, err := enc.DecodeParallel(dst, spaced)
+		if len(decoded) != len(tst) || !reflect.DeepEqual(err, terr) {
+			t.Errorf("bad err: %d made %d %v\n tst %d %v\n",
+				max, len(decoded), err, len(tst), terr)
+		}
+		if (max < skips) != errors.Is(err, ErrSkipLimit) {
+			t.Errorf("bad err: %d made %v\n", max, err)
+		}
+	}
+}
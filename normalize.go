@@ -0,0 +1,92 @@
+package base50
+
+import (
+	"unicode/utf8"
+)
+
+// normalizeRune returns the ASCII character r should be read as, or -1 if it
+// should be dropped. Runes which aren't known are returned unchanged.
+func normalizeRune(r rune) rune {
+	switch {
+	case r == '\u00A0', // No-break space
+		r == '\u1680',                  // Ogham space mark
+		'\u2000' <= r && r <= '\u200A', // En quad ... hair space
+		r == '\u202F',                  // Narrow no-break space
+		r == '\u205F',                  // Medium mathematical space
+		r == '\u3000':                  // Ideographic space
+		return ' '
+
+	case r == '\u2028', r == '\u2029': // Line and paragraph separators
+		return '\n'
+
+	case r == '\u00AD', // Soft hyphen
+		r == '\u180E',                  // Mongolian vowel separator
+		'\u200B' <= r && r <= '\u200D', // Zero width space, (non-)joiner
+		r == '\u2060',                  // Word joiner
+		r == '\uFEFF':                  // BOM, zero width no-break space
+		return -1
+
+	case '\uFF01' <= r && r <= '\uFF5E': // Fullwidth ASCII
+		return r - '\uFF01' + '!'
+	}
+
+	return r
+}
+
+// Normalize returns src with the Unicode characters that get added by copying
+// and pasting from chat tools, PDFs and word processors folded to ASCII.
+// Unicode spaces become ASCII spaces (which are skipped when decoding),
+// zero width characters, soft hyphens and BOMs are removed and fullwidth
+// digits and letters become ASCII digits and letters. Anything else is
+// unchanged, so invalid input still fails to decode.
+//
+// Decoding itself is strict, so Normalize needs to be called explicitly.
+// If src is all ASCII it is returned as is, otherwise a new slice is returned.
+func Normalize(src []byte) []byte {
+	i := 0
+	for i < len(src) && src[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(src) {
+		return src
+	}
+
+	// Normalizing never makes the input longer.
+	dst := make([]byte, i, len(src))
+	copy(dst, src[:i])
+	for i < len(src) {
+		if src[i] < utf8.RuneSelf {
+			dst = append(dst, src[i])
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(src[i:])
+		if r == utf8.RuneError && size == 1 { // Keep bad UTF-8 as is
+			dst = append(dst, src[i])
+			i++
+			continue
+		}
+
+		switch nr := normalizeRune(r); {
+		case nr == -1:
+		case nr < utf8.RuneSelf:
+			dst = append(dst, byte(nr))
+		default:
+			dst = append(dst, src[i:i+size]...)
+		}
+		i += size
+	}
+
+	return dst
+}
+
+// NormalizeString is like Normalize() but for strings.
+func NormalizeString(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return string(Normalize([]byte(s)))
+		}
+	}
+	return s
+}
@@ -0,0 +1,49 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBase50Normalize(t *testing.T) {
+	data := []struct {
+		val string
+		tst string
+	}{
+		{"", ""},
+		{"H1jP5eefyh", "H1jP5eefyh"},
+		{"H1jP5\u00A0eefyh", "H1jP5 eefyh"},
+		{"H1jP5\u200Beefyh", "H1jP5eefyh"},
+		{"\uFEFFH1jP5eefyh", "H1jP5eefyh"},
+		{"H1jP5\u00ADeefyh", "H1jP5eefyh"},
+		{"H1jP5\u2060\u200C\u200Deefyh", "H1jP5eefyh"},
+		{"H1jP5\u3000eefyh\u2028", "H1jP5 eefyh\n"},
+		{"\uFF28\uFF11\uFF4A\uFF30\uFF15eefyh", "H1jP5eefyh"},
+		{"1x\uFF0E", "1x."},
+		{"1x\u00E9", "1x\u00E9"},
+		{"1x\xFF", "1x\xFF"},
+	}
+
+	for i := range data {
+		got := Normalize([]byte(data[i].val))
+		if string(got) != data[i].tst {
+			t.Errorf("data not equal: %d: %q\n tst=<%q>\n got <%q>\n",
+				i, data[i].val, data[i].tst, got)
+		}
+		if NormalizeString(data[i].val) != data[i].tst {
+			t.Errorf("data not equal: %d: %q\n tst=<%q>\n got <%q>\n",
+				i, data[i].val, data[i].tst, NormalizeString(data[i].val))
+		}
+	}
+
+	// Normalizing isn't done by default.
+	val := []byte("\uFF28\uFF11\uFF4A\uFF30\uFF15\u00A0eefyh")
+	if _, err := Decode(make([]byte, DecodeLen(len(val))), val); !errors.Is(err, ErrInvalidChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	decoded, err := DecodeString(string(Normalize(val)))
+	if err != nil || !bytes.Equal(decoded, []byte("abcdefg")) {
+		t.Errorf("bad decode: %q %v\n", decoded, err)
+	}
+}
@@ -0,0 +1,146 @@
+package base50
+
+import (
+	"io"
+)
+
+// Ordered() encodings always use 10 characters for the last group, and give
+// it a value which sorts before the groups it's a prefix of. Eg. 0x01 sorts
+// before 0x0100 which sorts before 0x010000000000000000. So every group of 7
+// bytes num has the value:
+//
+//   num + num>>8 + num>>16 + num>>24 + num>>32 + num>>40 + num>>48 + 6
+//
+// leaving a value before it for each shorter group which pads to num with
+// zeros, Eg. 0x01000000000000 has 0x010000 (4 zeros) and 0x01 (6 zeros) just
+// before it. The 6 leaves room before num=0, which all shorter groups pad to.
+// This is upto 0xFFFF_FFFF_FFFF_FF * 256/255 which still fits in 10
+// characters, as zzzzz_zzzzz = (50**10)-1 = 97656249999999999.
+
+// orderedMax is the value of 0xFFFF_FFFF_FFFF_FF in Ordered() encodings.
+var orderedMax = orderedValue(0xFFFFFFFFFFFFFF)
+
+// orderedValue returns the value of the group of 7 bytes num in Ordered()
+// encodings.
+func orderedValue(num uint64) uint64 {
+	v := num + 6
+	for s := uint(8); s < 56; s += 8 {
+		v += num >> s
+	}
+	return v
+}
+
+// orderedGroup returns the value of the (upto) 7 bytes in src, in Ordered()
+// encodings.
+func orderedGroup(src []byte) uint64 {
+	var num uint64
+	for i := 0; i < 7; i++ {
+		num <<= 8
+		if i < len(src) {
+			num += uint64(src[i])
+		}
+	}
+	return orderedValue(num) - uint64(7-len(src))
+}
+
+// decodeOrdered decodes the 10 base50 characters in grp, with the value num,
+// into dst returning the number of bytes written. See orderedValue().
+func decodeOrdered(dst, grp []byte, num uint64) (int, error) {
+	if len(grp) != 10 || num > orderedMax {
+		return 0, InvalidTotalError(num)
+	}
+
+	// Guess num from the value, and then fix it.
+	v := num
+	if num > 6 {
+		num = (v - 6) - (v-6)/256
+	} else {
+		num = 0
+	}
+	for num > 0 && orderedValue(num-1) >= v {
+		num--
+	}
+	for orderedValue(num) < v {
+		num++
+	}
+
+	count := 7 - int(orderedValue(num)-v)
+	if len(dst) < count {
+		return 0, io.ErrShortBuffer
+	}
+	num >>= 8 * uint(7-count)
+	for i := count - 1; i >= 0; i-- {
+		dst[i] = byte(num & 0xFF)
+		num >>= 8
+	}
+
+	return count, nil
+}
+
+// Ordered returns a copy of enc where comparing encodings is the same as
+// comparing the data, even when it's a different length. The last group of
+// each message always has 10 characters, so encodings are longer than usual
+// (Eg. 0x00 is "0000000000."). This works with WithGroupCheck() but can't be
+// used with WithCheck() or Dense(), and ordered encodings can't be used with
+// DecodeRange().
+//
+// This is only true for comparing the strings when the alphabet is in ASCII
+// order, like Alphabet. Compare() works for any alphabet.
+func (enc *Encoding) Ordered() *Encoding {
+	e := *enc
+	e.ordered = true
+	e.check = false
+	e.dense = false
+	return &e
+}
+
+// rank returns the sort order of the encoded character c.
+func (enc *Encoding) rank(c byte) int {
+	switch {
+	case c == '.':
+		return -1
+	case enc.decodeMap[c] < stopClass:
+		return int(enc.decodeMap[c])
+	}
+	return 50 + int(c) // Invalid, sort after the alphabet
+}
+
+// Compare compares the base50 encodings a and b, ignoring whitespace and
+// underbars. The result is 0 if a==b, -1 if a < b, and +1 if a > b. For
+// Ordered() encodings this is the same as bytes.Compare() of the data.
+func Compare(a, b []byte) int {
+	return StdEncoding.Compare(a, b)
+}
+
+// Compare compares the base50 encodings a and b using the alphabet of enc,
+// see Compare().
+func (enc *Encoding) Compare(a, b []byte) int {
+	i, j := 0, 0
+	for {
+		for i < len(a) && skipChar(a[i]) {
+			i++
+		}
+		for j < len(b) && skipChar(b[j]) {
+			j++
+		}
+
+		switch {
+		case i == len(a) && j == len(b):
+			return 0
+		case i == len(a):
+			return -1
+		case j == len(b):
+			return +1
+		}
+
+		ra, rb := enc.rank(a[i]), enc.rank(b[j])
+		if ra < rb {
+			return -1
+		}
+		if ra > rb {
+			return +1
+		}
+		i++
+		j++
+	}
+}
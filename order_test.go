@@ -0,0 +1,116 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestBase50Ordered(t *testing.T) {
+	oenc := StdEncoding.Ordered()
+
+	data := []struct {
+		val []byte
+		enc string
+	}{
+		{[]byte{}, ""},
+		{[]byte{0x00}, "0000000000."},
+		{[]byte{0x00, 0x00}, "0000000001."},
+		{[]byte{0, 0, 0, 0, 0, 0}, "0000000005."},
+		{[]byte{0, 0, 0, 0, 0, 0, 0}, "0000000006"},
+		{[]byte{0, 0, 0, 0, 0, 0, 1}, "0000000007"},
+	}
+	for i := range data {
+		encoded := oenc.EncodeToString(data[i].val)
+		if encoded != data[i].enc {
+			t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+				i, data[i].enc, encoded)
+		}
+	}
+
+	// Use lots of 0x00 and 0xFF, as those are the edge cases.
+	bvals := []byte{0x00, 0x00, 0x01, 0x7F, 0xFE, 0xFF, 0xFF}
+	rnd := rand.New(rand.NewSource(1))
+	var vals [][]byte
+	for i := 0; i < 2000; i++ {
+		val := make([]byte, rnd.Intn(17))
+		for j := range val {
+			val[j] = bvals[rnd.Intn(len(bvals))]
+		}
+		vals = append(vals, val)
+	}
+
+	for _, enc := range []*Encoding{oenc, oenc.WithGroupCheck()} {
+		encs := make([][]byte, len(vals))
+		for i, val := range vals {
+			encs[i] = enc.EncodeToBytes(val)
+			if len(encs[i]) != enc.EncodeLen(len(val)) {
+				t.Errorf("bad len: %x: <%s>\n", val, encs[i])
+			}
+
+			decoded, err := enc.Strict().Decode(make([]byte, len(val)), encs[i])
+			if err != nil || !bytes.Equal(decoded, val) {
+				t.Errorf("bad decode: %x: <%s> made %x %v\n",
+					val, encs[i], decoded, err)
+			}
+		}
+
+		for i := 1; i < len(vals); i++ {
+			tst := bytes.Compare(vals[i-1], vals[i])
+			if c := strings.Compare(string(encs[i-1]), string(encs[i])); c != tst {
+				t.Errorf("bad order: %x %x\n tst=%d\n got %d\n",
+					vals[i-1], vals[i], tst, c)
+			}
+			if c := enc.Compare(encs[i-1], encs[i]); c != tst {
+				t.Errorf("bad compare: %x %x\n tst=%d\n got %d\n",
+					vals[i-1], vals[i], tst, c)
+			}
+		}
+	}
+
+	if _, err := oenc.DecodeString("zzzzzzzzzz"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := oenc.DecodeString("1x."); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := oenc.Strict().DecodeString("0000000000"); !errors.Is(err, ErrStopChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := oenc.Strict().DecodeString("0000000000.0000000006"); !errors.Is(err, ErrStopChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if oenc.WithCheck().ordered || oenc.WithCheck().Ordered().check {
+		t.Errorf("WithCheck() and Ordered() mixed\n")
+	}
+}
+
+func TestBase50Compare(t *testing.T) {
+	data := []struct {
+		a, b string
+		tst  int
+	}{
+		{"", "", 0},
+		{"", "0", -1},
+		{"1x.", "1x", +1},
+		{"1x", "1x_", 0},
+		{"1x.", "1x0", -1},
+		{"z", "Z", +1},
+		{"a", "Z", +1},
+		{"A", "9", +1},
+		{"!", "z", +1},
+	}
+
+	for i := range data {
+		if c := Compare([]byte(data[i].a), []byte(data[i].b)); c != data[i].tst {
+			t.Errorf("bad compare: %d: %q %q made %d\n",
+				i, data[i].a, data[i].b, c)
+		}
+		if c := Compare([]byte(data[i].b), []byte(data[i].a)); c != -data[i].tst {
+			t.Errorf("bad compare: %d: %q %q made %d\n",
+				i, data[i].b, data[i].a, c)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package base50
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// parallelShard is the least number of input bytes for each goroutine of
+// EncodeParallel() and DecodeParallel(), anything smaller isn't worth it.
+const parallelShard = 64 * 1024
+
+// shards returns the number of shards to split n bytes of input into.
+func shards(n int) int {
+	num := runtime.GOMAXPROCS(0)
+	if max := n / parallelShard; num > max {
+		num = max
+	}
+	if num < 1 {
+		return 1
+	}
+	return num
+}
+
+// EncodeParallel encodes src into dst like Encode(), but splits large inputs
+// into shards of whole groups which are encoded by different goroutines, upto
+// GOMAXPROCS. Small inputs are encoded like Encode(), and the output is
+// always the same.
+func EncodeParallel(dst, src []byte) []byte {
+	return StdEncoding.EncodeParallel(dst, src)
+}
+
+// EncodeParallel encodes src using the encoding enc, see EncodeParallel().
+func (enc *Encoding) EncodeParallel(dst, src []byte) []byte {
+	num := shards(len(src))
+	if num == 1 {
+		return enc.Encode(dst, src)
+	}
+	_ = dst[:enc.EncodeLen(len(src))] // Panic here, and not in a goroutine
+
+	// Every full group is groupLen() characters, so the output of each
+	// shard is at a known offset.
+	gb, glen := enc.groupBytes(), enc.groupLen()
+	groups := (len(src)/gb + num - 1) / num
+	ns := make([]int, num)
+	var wg sync.WaitGroup
+	for i := 0; i < num; i++ {
+		start := i * groups * gb
+		end := start + groups*gb
+		if i == num-1 || end > len(src) {
+			end = len(src)
+		}
+		if start >= end {
+			break
+		}
+
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+			off := start / gb * glen
+			n, _ := enc.encodeGroups(dst[off:], src[start:end])
+			ns[i] = off + n
+		}(i, start, end)
+	}
+	wg.Wait()
+
+	n := 0
+	for _, end := range ns {
+		if end > n {
+			n = end
+		}
+	}
+	return dst[:enc.format(dst, enc.encodeEnd(dst, n), 0)]
+}
+
+// A decodeShard is the part of the input decoded by one goroutine of
+// DecodeParallel(), which starts at a group boundary.
+type decodeShard struct {
+	start int   // offset in the input
+	group int   // number of groups before start
+	count int   // number of bytes decoded
+	skip  int64 // number of skipChar()s before start, for WithMaxSkipped()
+	err   error
+	g     groupDecoder
+}
+
+// splitDecode returns where to split src into shards of whole groups, or nil
+// if it can't be split. There can't be stop characters before the end,
+// because those can end a group early. The skipChar()s before each shard are
+// counted, so the WithMaxSkipped() limit is over the whole input.
+func (enc *Encoding) splitDecode(src []byte) []decodeShard {
+	num := shards(len(src))
+	if num == 1 || enc.check {
+		return nil
+	}
+
+	glen := enc.groupLen()
+	end := trimEnd(src)
+	ret := []decodeShard{{}}
+	chars := 0
+	var skip int64
+	next := len(src) / num
+	for i, c := range src[:end] {
+		switch enc.decodeMap[c] {
+		case stopClass:
+			return nil
+		case skipClass:
+			skip++
+			continue
+		}
+
+		if i >= next && chars%glen == 0 && len(ret) < num {
+			ret = append(ret, decodeShard{start: i, group: chars / glen,
+				skip: skip})
+			next = i + len(src)/num
+		}
+		chars++
+	}
+
+	return ret
+}
+
+// DecodeParallel decodes src into dst like Decode(), but splits large inputs
+// into shards of whole groups which are decoded by different goroutines, upto
+// GOMAXPROCS. Small inputs, WithCheck() encodings and inputs with multiple
+// messages are decoded like Decode(). The output and any error are always the
+// same as Decode().
+func DecodeParallel(dst, src []byte) ([]byte, error) {
+	return StdEncoding.DecodeParallel(dst, src)
+}
+
+// DecodeParallel decodes src using the encoding enc, see DecodeParallel().
+func (enc *Encoding) DecodeParallel(dst, src []byte) ([]byte, error) {
+	return enc.DecodeParallelContext(context.Background(), dst, src)
+}
+
+// DecodeParallelContext decodes src into dst like DecodeParallel(), but stops
+// with the error from ctx when ctx is done. This is checked after decoding
+// every parallelShard characters of each shard, and inputs which are decoded
+// like Decode() are also checked.
+func DecodeParallelContext(ctx context.Context, dst, src []byte) ([]byte, error) {
+	return StdEncoding.DecodeParallelContext(ctx, dst, src)
+}
+
+// DecodeParallelContext decodes src using the encoding enc, see
+// DecodeParallelContext().
+func (enc *Encoding) DecodeParallelContext(ctx context.Context, dst, src []byte) ([]byte, error) {
+	shards := enc.splitDecode(src)
+	if shards == nil { // A single shard, with everything Decode() does
+		shards = []decodeShard{{}}
+	}
+
+	gb := enc.groupBytes()
+	var wg sync.WaitGroup
+	for i := range shards {
+		s := &shards[i]
+		end := len(src)
+		if i < len(shards)-1 {
+			end = shards[i+1].start
+		}
+
+		// Each shard, apart from the last, is whole groups.
+		off := s.group * gb
+		out := dst[len(dst):]
+		if off < len(dst) {
+			out = dst[off:]
+		}
+		if i < len(shards)-1 && len(out) > (shards[i+1].group-s.group)*gb {
+			out = out[:(shards[i+1].group-s.group)*gb]
+		}
+		s.g = groupDecoder{enc: enc, stop: i == 0, off: s.start,
+			group: s.group, count: off, skipped: s.skip}
+
+		wg.Add(1)
+		if len(shards) == 1 {
+			s.decode(ctx, out, src[s.start:end], true, &wg)
+		} else {
+			go s.decode(ctx, out, src[s.start:end], i == len(shards)-1, &wg)
+		}
+	}
+	wg.Wait()
+
+	var failed GroupCheckError
+	for i := range shards {
+		s := &shards[i]
+		if s.err != nil {
+			return dst[:s.group*gb+s.g.verified(s.count)], s.err
+		}
+		failed = append(failed, s.g.failed...)
+	}
+
+	last := shards[len(shards)-1]
+	count := last.group*gb + last.count
+	if failed != nil {
+		return dst[:count], failed
+	}
+	return dst[:count], nil
+}
+
+// decode decodes the shard src into dst, upto parallelShard characters at
+// once so ctx can be checked.
+func (s *decodeShard) decode(ctx context.Context, dst, src []byte, last bool,
+	wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for len(src) > 0 && s.err == nil {
+		if s.err = ctx.Err(); s.err != nil {
+			return
+		}
+
+		chunk := src
+		if len(chunk) > parallelShard {
+			chunk = chunk[:parallelShard]
+		}
+		src = src[len(chunk):]
+
+		var n int
+		n, s.err = s.g.decode(dst[s.count:], chunk)
+		s.count += n
+	}
+
+	if s.err == nil && last {
+		var n int
+		n, s.err = s.g.end(dst[s.count:])
+		s.count += n
+	}
+}
@@ -0,0 +1,124 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestBase50Parallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	val := make([]byte, 5*parallelShard+123)
+	rand.New(rand.NewSource(1)).Read(val)
+
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithGroupCheck(),
+		StdEncoding.WithCheck(), StdEncoding.Dense(), StdEncoding.Ordered(),
+		StdEncoding.Strict(), withSeparator(StdEncoding.WithLineWrap(0), '-', 5)} {
+		for _, l := range []int{0, 100, parallelShard, len(val)} {
+			encoded := enc.EncodeToBytes(val[:l])
+			penc := enc.EncodeParallel(make([]byte, enc.EncodeLen(l)), val[:l])
+			if !bytes.Equal(penc, encoded) {
+				t.Errorf("encoding not equal: %d\n", l)
+			}
+
+			decoded, err := enc.DecodeParallel(make([]byte, l), encoded)
+			if err != nil || !bytes.Equal(decoded, val[:l]) {
+				t.Errorf("bad decode: %d made %v\n", l, err)
+			}
+		}
+	}
+
+	// Whitespace moves the group boundaries.
+	encoded := EncodeToBytes(val)
+	spaced := bytes.Replace(encoded, []byte("0"), []byte(" 0\n"), -1)
+	decoded, err := DecodeParallel(make([]byte, len(val)), spaced)
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad decode: %v\n", err)
+	}
+
+	// Multiple messages are decoded serially.
+	multi := append(append([]byte(nil), encoded...), '.')
+	multi = append(multi, encoded...)
+	decoded, err = DecodeParallel(make([]byte, 2*len(val)), multi)
+	if err != nil || !bytes.Equal(decoded[len(val):], val) {
+		t.Errorf("bad decode: %v\n", err)
+	}
+}
+
+func TestBase50ParallelErrors(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	val := make([]byte, 5*parallelShard)
+	rand.New(rand.NewSource(1)).Read(val)
+
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithGroupCheck()} {
+		encoded := enc.EncodeToBytes(val)
+		for _, off := range []int{5, len(encoded) / 2, len(encoded) - 3} {
+			bad := append([]byte(nil), encoded...)
+			bad[off] = '!'
+			bad[len(bad)-off] = '!'
+
+			dst := make([]byte, len(val))
+			tst, terr := enc.Decode(dst, bad)
+			decoded, err := enc.DecodeParallel(dst, bad)
+			if len(decoded) != len(tst) || !reflect.DeepEqual(err, terr) {
+				t.Errorf("bad err: %d made %d %v\n tst %d %v\n",
+					off, len(decoded), err, len(tst), terr)
+			}
+		}
+
+		// A short dst is the same error as Decode().
+		dst := make([]byte, len(val)/2)
+		_, terr := enc.Decode(dst, encoded)
+		_, err := enc.DecodeParallel(dst, encoded)
+		if !errors.Is(err, io.ErrShortBuffer) || !reflect.DeepEqual(err, terr) {
+			t.Errorf("bad err: %v\n tst %v\n", err, terr)
+		}
+	}
+
+	// Group check failures are all returned.
+	gcenc := StdEncoding.WithGroupCheck()
+	encoded := gcenc.EncodeToBytes(val)
+	bad := append([]byte(nil), encoded...)
+	for _, off := range []int{0, len(bad) / 3, len(bad) / 2} {
+		off = off / 11 * 11
+		bad[off], bad[off+1] = bad[off+1], bad[off]
+		if bad[off] == bad[off+1] {
+			bad[off] = Alphabet[(gcenc.decodeMap[bad[off]]+1)%50]
+		}
+	}
+	_, terr := gcenc.Decode(make([]byte, len(val)), bad)
+	_, err := gcenc.DecodeParallel(make([]byte, len(val)), bad)
+	var gerr GroupCheckError
+	if !errors.As(err, &gerr) || len(gerr) != 3 || !reflect.DeepEqual(err, terr) {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
+
+func BenchmarkEncodeParallel(b *testing.B) {
+	val := benchData(4 << 20)
+	dst := make([]byte, EncodeLen(len(val)))
+
+	b.SetBytes(int64(len(val)))
+	for i := 0; i < b.N; i++ {
+		EncodeParallel(dst, val)
+	}
+}
+
+func BenchmarkDecodeParallel(b *testing.B) {
+	val := benchData(4 << 20)
+	encoded := EncodeToBytes(val)
+	dst := make([]byte, len(val))
+
+	b.SetBytes(int64(len(val)))
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeParallel(dst, encoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrNoRandomAccess is for random access to an encoding which doesn't allow
+// it, Eg. WithCheck() or Ordered() encodings, or a source whose size isn't
+// known.
+var ErrNoRandomAccess = errors.New("base50: random access not possible")
+
+// ErrInvalidRange is for random access with a negative offset or length.
+var ErrInvalidRange = errors.New("base50: invalid range")
+
+// trimEnd returns the length of src without the trailing whitespace/underbars
+// and the stop character, and any whitespace/underbars before that.
+func trimEnd(src []byte) int {
+	n := trimSkip(src)
+	if n > 0 && src[n-1] == '.' {
+		n = trimSkip(src[:n-1])
+	}
+	return n
+}
+
+// trimSkip returns the length of src without the trailing whitespace/underbars.
+func trimSkip(src []byte) int {
+	n := len(src)
+	for n > 0 && skipChar(src[n-1]) {
+		n--
+	}
+	return n
+}
+
+// decodedSize returns the number of bytes in the decoding of a single
+// message of n base50 characters, with no stop character.
+func (enc *Encoding) decodedSize(n int64) (int64, error) {
+	if enc.check || enc.ordered {
+		return 0, ErrNoRandomAccess
+	}
+
+	glen := int64(enc.groupLen())
+	gb := int64(enc.groupBytes())
+	tail := n % glen
+	if enc.groupCheck && tail > 0 {
+		tail--
+		if tail == 0 {
+			return 0, &DecodeError{Offset: int(n - 1), Group: int(n / glen),
+				DecodedOffset: int(n / glen * gb), Err: ErrTruncatedGroup}
+		}
+	}
+	return n/glen*gb + int64(enc.DecodeLen(int(tail))), nil
+}
+
+// decodeRange decodes the groups in src, starting with group number group,
+// into dst skipping the first skip bytes. It returns the number of bytes
+// written, which stops when dst is full.
+func (enc *Encoding) decodeRange(dst, src []byte, group, skip int) (int, error) {
+	glen := enc.groupLen()
+	var buf [31]byte
+
+	n := 0
+	for i := 0; i < len(src) && n < len(dst); i += glen {
+		grp := src[i:]
+		if len(grp) > glen {
+			grp = grp[:glen]
+		}
+		derr := &DecodeError{Offset: group * glen, Group: group,
+			DecodedOffset: group * enc.groupBytes()}
+
+		if enc.groupCheck {
+			chk := grp[len(grp)-1]
+			grp = grp[:len(grp)-1]
+			if enc.decodeMap[chk] >= stopClass {
+				derr.Offset += len(grp)
+				derr.Err = InvalidByteError(chk)
+				return n, derr
+			}
+			if enc.checkChar(grp) != enc.encode[enc.decodeMap[chk]] {
+				derr.Err = ErrCheck
+				return n, derr
+			}
+		}
+
+		num, err := enc.decodeGroup(buf[:], grp)
+		if err != nil {
+			if c, ok := err.(InvalidByteError); ok {
+				derr.Offset += bytes.IndexByte(grp, byte(c))
+			}
+			derr.Err = err
+			return n, derr
+		}
+		n += copy(dst[n:], buf[skip:num])
+		skip = 0
+		group++
+	}
+
+	return n, nil
+}
+
+// DecodeRange decodes n bytes from offset off of the decoding of src, only
+// decoding the groups needed. Every 7 bytes of the decoding are a 10 character
+// group (or 31 bytes in 44 characters for Dense()), so this only works when
+// src is a single message, without any whitespace/underbars or stop
+// characters (apart from at the end). If the decoding ends before off+n it
+// returns the bytes before the end and io.EOF, like io.ReaderAt.
+func DecodeRange(src []byte, off, n int) ([]byte, error) {
+	return StdEncoding.DecodeRange(src, off, n)
+}
+
+// DecodeRange decodes a range of src using the encoding enc, see
+// DecodeRange(). WithCheck() and Ordered() encodings return
+// ErrNoRandomAccess.
+func (enc *Encoding) DecodeRange(src []byte, off, n int) ([]byte, error) {
+	if off < 0 || n < 0 {
+		return nil, ErrInvalidRange
+	}
+
+	src = src[:trimEnd(src)]
+	size, err := enc.decodedSize(int64(len(src)))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(off) >= size {
+		return nil, io.EOF
+	}
+	if int64(n) > size-int64(off) { // off+n can overflow
+		n = int(size - int64(off))
+		err = io.EOF
+	}
+	if n == 0 {
+		return nil, err
+	}
+
+	glen, gb := enc.groupLen(), enc.groupBytes()
+	group := off / gb
+	end := ((off+n-1)/gb + 1) * glen
+	if end > len(src) {
+		end = len(src)
+	}
+
+	dst := make([]byte, n)
+	num, derr := enc.decodeRange(dst, src[group*glen:end], group, off%gb)
+	if derr != nil {
+		return dst[:num], derr
+	}
+	return dst, err
+}
+
+// A RangeReader reads the decoding of a base50 message, from an
+// io.ReaderAt, only decoding the groups needed. See DecodeRange().
+// It implements io.ReaderAt, io.Reader and io.Seeker.
+type RangeReader struct {
+	enc  *Encoding
+	r    io.ReaderAt
+	n    int64 // number of base50 characters, without a stop character
+	size int64 // number of decoded bytes
+	off  int64 // offset for Read() and Seek()
+}
+
+// NewReaderAt returns a RangeReader for the base50 message in r. The size of
+// r is found with a Size() method (like bytes.Reader or io.SectionReader),
+// or by seeking to the end when r is an io.Seeker, otherwise it returns
+// ErrNoRandomAccess.
+func NewReaderAt(r io.ReaderAt) (*RangeReader, error) {
+	return StdEncoding.NewReaderAt(r)
+}
+
+// NewReaderAt returns a RangeReader for r using the encoding enc, see
+// NewReaderAt(). WithCheck() and Ordered() encodings return
+// ErrNoRandomAccess.
+func (enc *Encoding) NewReaderAt(r io.ReaderAt) (*RangeReader, error) {
+	var n int64
+	switch s := r.(type) {
+	case interface{ Size() int64 }:
+		n = s.Size()
+	case io.Seeker:
+		cur, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		if n, err = s.Seek(0, io.SeekEnd); err != nil {
+			return nil, err
+		}
+		if _, err = s.Seek(cur, io.SeekStart); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrNoRandomAccess
+	}
+
+	// Remove any stop character and whitespace at the end, which can be
+	// split over reads.
+	var buf [16]byte
+	stop := false // the stop character has been removed
+	for n > 0 {
+		end := buf[:]
+		if n < int64(len(end)) {
+			end = end[:n]
+		}
+		if _, err := r.ReadAt(end, n-int64(len(end))); err != nil &&
+			err != io.EOF {
+			return nil, err
+		}
+		t := trimSkip(end)
+		if !stop && t > 0 && end[t-1] == '.' {
+			stop = true
+			t = trimSkip(end[:t-1])
+		}
+		n -= int64(len(end) - t)
+		if t > 0 {
+			break
+		}
+	}
+
+	size, err := enc.decodedSize(n)
+	if err != nil {
+		return nil, err
+	}
+	return &RangeReader{enc: enc, r: r, n: n, size: size}, nil
+}
+
+// Size returns the number of bytes in the decoding.
+func (rr *RangeReader) Size() int64 {
+	return rr.size
+}
+
+// ReadAt reads len(p) bytes of the decoding from offset off.
+func (rr *RangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrInvalidRange
+	}
+	if off >= rr.size {
+		return 0, io.EOF
+	}
+
+	var eof error
+	if int64(len(p)) > rr.size-off {
+		p = p[:rr.size-off]
+		eof = io.EOF
+	}
+
+	// Read upto 100 groups at once (25 for Dense()).
+	var buf [1100]byte
+	glen := int64(rr.enc.groupLen())
+	gb := int64(rr.enc.groupBytes())
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		group := pos / gb
+		end := ((pos+int64(len(p)-n)-1)/gb + 1) * glen
+		if end > group*glen+int64(len(buf))/glen*glen {
+			end = group*glen + int64(len(buf))/glen*glen
+		}
+		if end > rr.n {
+			end = rr.n
+		}
+
+		src := buf[:end-group*glen]
+		if m, err := rr.r.ReadAt(src, group*glen); m < len(src) {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, err
+		}
+		num, err := rr.enc.decodeRange(p[n:], src, int(group), int(pos%gb))
+		n += num
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, eof
+}
+
+// Read reads upto len(p) bytes of the decoding, see io.Reader.
+func (rr *RangeReader) Read(p []byte) (int, error) {
+	if rr.off >= rr.size {
+		return 0, io.EOF
+	}
+	n, err := rr.ReadAt(p, rr.off)
+	rr.off += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the offset for the next Read, see io.Seeker.
+func (rr *RangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += rr.off
+	case io.SeekEnd:
+		offset += rr.size
+	default:
+		return 0, errors.New("base50: invalid whence")
+	}
+	if offset < 0 {
+		return 0, ErrInvalidRange
+	}
+	rr.off = offset
+	return offset, nil
+}
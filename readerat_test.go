@@ -0,0 +1,151 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBase50DecodeRange(t *testing.T) {
+	val := []byte("abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithGroupCheck()} {
+		for l := 0; l <= 30; l++ {
+			encoded := enc.EncodeToBytes(val[:l])
+			for off := 0; off <= l; off++ {
+				for n := 0; n <= l-off+2; n++ {
+					decoded, err := enc.DecodeRange(encoded, off, n)
+					tst := val[off:l]
+					if len(tst) > n {
+						tst = tst[:n]
+					}
+					if !bytes.Equal(decoded, tst) {
+						t.Errorf("data not equal: %d/%d/%d\n tst=<%s>\n got <%s>\n",
+							l, off, n, tst, decoded)
+					}
+					if (off+n > l || off == l) != (err == io.EOF) || (err != nil && err != io.EOF) {
+						t.Errorf("bad err: %d/%d/%d made %v\n", l, off, n, err)
+					}
+				}
+			}
+		}
+	}
+
+	// Only the groups in the range are decoded.
+	encoded := EncodeToString(val[:21])
+	bad := encoded[:10] + "!" + encoded[11:]
+	decoded, err := DecodeRange([]byte(bad), 14, 7)
+	if err != nil || string(decoded) != string(val[14:21]) {
+		t.Errorf("bad range: <%s> made %q %v\n", bad, decoded, err)
+	}
+	_, err = DecodeRange([]byte(bad), 3, 7)
+	var derr *DecodeError
+	if !errors.As(err, &derr) || !errors.Is(err, ErrInvalidChar) ||
+		derr.Offset != 10 || derr.Group != 1 || derr.DecodedOffset != 7 {
+		t.Errorf("bad err: <%s> made %v\n", bad, err)
+	}
+
+	// Ranges past the end are cut short, even when off+n overflows.
+	maxInt := int(^uint(0) >> 1) // math.MaxInt, which needs go 1.17
+	src := EncodeToBytes(val[:16])
+	for _, r := range []struct {
+		off, n int
+		tst    string
+	}{
+		{1, maxInt, string(val[1:16])},
+		{15, maxInt - 14, string(val[15:16])},
+		{maxInt, maxInt, ""},
+		{10, 7, string(val[10:16])},
+		{9, 8, string(val[9:16])},
+	} {
+		decoded, err := DecodeRange(src, r.off, r.n)
+		if err != io.EOF || string(decoded) != r.tst {
+			t.Errorf("bad range: %d/%d made %q %v\n", r.off, r.n, decoded, err)
+		}
+	}
+
+	// Whitespace before the stop character is trimmed too.
+	decoded, err = DecodeRange([]byte("H1jP5eefyh112sa ."), 7, 3)
+	if err != nil || string(decoded) != "abc" {
+		t.Errorf("bad range: made %q %v\n", decoded, err)
+	}
+
+	if _, err := DecodeRange([]byte(encoded), -1, 1); err != ErrInvalidRange {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := checkEncoding.DecodeRange([]byte(encoded), 0, 1); err != ErrNoRandomAccess {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
+
+func TestBase50ReaderAt(t *testing.T) {
+	val := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz0123456789"), 100)
+
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithGroupCheck()} {
+		encoded := enc.EncodeToBytes(val)
+
+		for _, suffix := range []string{"", "\n", ".\n  \n\t\n\n\n\n\n\n\n\n\n\n\n\n",
+			" .", " \n\t.\n", strings.Repeat(" ", 20) + ".\n"} {
+			src := append(append([]byte(nil), encoded[:len(encoded)-1]...), suffix...)
+			rr, err := enc.NewReaderAt(bytes.NewReader(src))
+			if err != nil {
+				t.Fatalf("bad err: %v\n", err)
+			}
+			if rr.Size() != int64(len(val)) {
+				t.Errorf("bad size: %q %d\n", suffix, rr.Size())
+			}
+
+			for _, r := range [][2]int{{0, 1}, {5, 3}, {7, 7}, {13, 1200},
+				{1000, 2600}, {3590, 10}} {
+				p := make([]byte, r[1])
+				n, err := rr.ReadAt(p, int64(r[0]))
+				tst := val[r[0]:]
+				if len(tst) > r[1] {
+					tst = tst[:r[1]]
+				}
+				if !bytes.Equal(p[:n], tst) {
+					t.Errorf("data not equal: %v\n", r)
+				}
+				if (len(tst) < r[1]) != (err == io.EOF) ||
+					(err != nil && err != io.EOF) {
+					t.Errorf("bad err: %v made %v\n", r, err)
+				}
+			}
+
+			if _, err := rr.Seek(-10, io.SeekEnd); err != nil {
+				t.Fatalf("bad err: %v\n", err)
+			}
+			decoded, err := ioutil.ReadAll(rr)
+			if err != nil || !bytes.Equal(decoded, val[len(val)-10:]) {
+				t.Errorf("bad read: %q %v\n", decoded, err)
+			}
+		}
+	}
+
+	// Sizes come from Seek() for files.
+	f, err := ioutil.TempFile("", "base50")
+	if err != nil {
+		t.Fatalf("bad err: %v\n", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := NewEncoder(f).Write(val); err != nil {
+		t.Fatalf("bad err: %v\n", err)
+	}
+	rr, err := NewReaderAt(f)
+	if err != nil || rr.Size() != int64(len(val))/7*7 {
+		t.Fatalf("bad err: %v\n", err)
+	}
+	decoded, err := ioutil.ReadAll(rr)
+	if err != nil || !bytes.Equal(decoded, val[:rr.Size()]) {
+		t.Errorf("bad read: %v\n", err)
+	}
+
+	if _, err := NewReaderAt(struct{ io.ReaderAt }{strings.NewReader("")}); err != ErrNoRandomAccess {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
@@ -0,0 +1,182 @@
+package base50
+
+import (
+	"io"
+	"math/bits"
+)
+
+// The secret functions use the same encoding as FixedLength(), but the
+// branches and memory accesses only depend on the length of the input and
+// not the data. Every group is the same number of characters for the same
+// number of bytes, the alphabet is looked at one character at a time instead
+// of indexing a table with the data, and dividing by 50 is a multiply (which
+// is a software division on 32-bit CPUs otherwise).
+
+// secretChars is the number of characters for n bytes of a group, these are
+// never shortened.
+var secretChars = [8]int{0, 2, 3, 5, 6, 8, 9, 10}
+
+// secretBytes is the number of bytes for n characters of a group, -1 for
+// lengths which are only used for shortened groups.
+var secretBytes = [11]int{0, -1, 1, 2, -1, 3, 4, -1, 5, 6, 7}
+
+// ctEq returns all ones if a == b, and 0 otherwise.
+func ctEq(a, b uint64) uint64 {
+	x := a ^ b
+	return ((x | -x) >> 63) - 1
+}
+
+// ctNonZero returns 1 if x != 0, and 0 otherwise.
+func ctNonZero(x uint64) uint64 {
+	return (x | -x) >> 63
+}
+
+// divmod50 returns num/50 and num%50 for num < 2**60, by multiplying with
+// ceil(2**69/50) instead of dividing.
+func divmod50(num uint64) (uint64, uint64) {
+	hi, _ := bits.Mul64(num, 11805916207174113035)
+	q := hi >> 5
+	return q, num - q*50
+}
+
+// ctChar returns the character for the value v, looking at all of the
+// alphabet.
+func (enc *Encoding) ctChar(v uint64) byte {
+	var c uint64
+	for i := range enc.encode {
+		c |= uint64(enc.encode[i]) & ctEq(uint64(i), v)
+	}
+	return byte(c)
+}
+
+// ctValue returns the value of the character c, and 1 if it's in the
+// alphabet (0 otherwise), looking at all of the alphabet.
+func (enc *Encoding) ctValue(c byte) (uint64, uint64) {
+	var v, ok uint64
+	for i := range enc.encode {
+		m := ctEq(uint64(enc.encode[i]), uint64(c))
+		v |= uint64(i) & m
+		ok |= m
+	}
+	return v, ok & 1
+}
+
+// EncodeSecret encodes src into dst like Encode(), but in constant time for
+// secret data like keys. The output is the same as FixedLength(), so the
+// length only depends on len(src), and is always EncodeLen(len(src)).
+//
+// Like Encode(), EncodeSecret panics if dst is too small.
+func EncodeSecret(dst, src []byte) []byte {
+	return StdEncoding.EncodeSecret(dst, src)
+}
+
+// EncodeSecret encodes src using the alphabet of the encoding enc, see
+// EncodeSecret(). Other options of enc (Eg. WithCheck(), Ordered() or
+// Dense()) aren't used.
+func (enc *Encoding) EncodeSecret(dst, src []byte) []byte {
+	_ = dst[:encodeLen(len(src))] // Panic before writing anything
+
+	n := 0
+	for len(src) > 0 {
+		grp := src
+		if len(grp) > 7 {
+			grp = grp[:7]
+		}
+		src = src[len(grp):]
+
+		var num uint64
+		for _, b := range grp {
+			num = num<<8 | uint64(b)
+		}
+
+		outb := secretChars[len(grp)]
+		for i := outb - 1; i >= 0; i-- {
+			var r uint64
+			num, r = divmod50(num)
+			dst[n+i] = enc.ctChar(r)
+		}
+		n += outb
+
+		if len(grp) < 7 {
+			dst[n] = '.'
+			n++
+		}
+	}
+
+	return dst[:n]
+}
+
+// DecodeSecret decodes src into dst like Decode(), but in constant time for
+// secret data like keys. It only decodes what EncodeSecret() outputs, so
+// there can't be whitespace/underbars or shortened groups, and the stop
+// character is optional. dst can be src, to decode in place.
+//
+// The error doesn't say where the input is bad, as that depends on the data,
+// so it's ErrInvalidChar, ErrOverflow or ErrNonCanonical and not a
+// DecodeError. Any error is only known at the end, so dst is zeroed.
+func DecodeSecret(dst, src []byte) ([]byte, error) {
+	return StdEncoding.DecodeSecret(dst, src)
+}
+
+// DecodeSecret decodes src using the alphabet of the encoding enc, see
+// DecodeSecret(). Other options of enc (Eg. WithCheck(), Ordered() or
+// Dense()) aren't used.
+func (enc *Encoding) DecodeSecret(dst, src []byte) ([]byte, error) {
+	if len(src) > 0 && src[len(src)-1] == '.' {
+		src = src[:len(src)-1]
+	}
+
+	tail := secretBytes[len(src)%10]
+	if tail < 0 {
+		return nil, ErrNonCanonical
+	}
+	count := len(src)/10*7 + tail
+	if len(dst) < count {
+		return nil, io.ErrShortBuffer
+	}
+
+	// Each group is read before it's written, so dst can be src.
+	var bad, over, big uint64
+	n := 0
+	for len(src) > 0 {
+		grp := src
+		if len(grp) > 10 {
+			grp = grp[:10]
+		}
+		src = src[len(grp):]
+
+		var num uint64
+		for _, c := range grp {
+			v, ok := enc.ctValue(c)
+			bad |= ok ^ 1
+			num = num*50 + v
+		}
+
+		gb := secretBytes[len(grp)]
+		if gb == 7 {
+			over |= ctNonZero(num >> 56)
+		} else {
+			big |= ctNonZero(num >> uint(8*gb))
+		}
+		for i := gb - 1; i >= 0; i-- {
+			dst[n+i] = byte(num)
+			num >>= 8
+		}
+		n += gb
+	}
+
+	if bad|over|big != 0 {
+		for i := range dst[:count] {
+			dst[i] = 0
+		}
+	}
+	switch {
+	case bad != 0:
+		return nil, ErrInvalidChar
+	case over != 0:
+		return nil, ErrOverflow
+	case big != 0:
+		return nil, ErrNonCanonical
+	}
+	return dst[:count], nil
+}
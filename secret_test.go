@@ -0,0 +1,114 @@
+package base50
+
+import (
+	"bytes"
+	"testing"
+	"testing/quick"
+)
+
+func TestBase50CtEq(t *testing.T) {
+	ctEqRef := func(a, b uint64) uint64 {
+		if a == b {
+			return ^uint64(0)
+		}
+		return 0
+	}
+	if err := quick.CheckEqual(ctEq, ctEqRef, nil); err != nil {
+		t.Error(err)
+	}
+
+	for _, x := range []uint64{0, 1, 49, 50, 1 << 63, ^uint64(0)} {
+		if ctEq(x, x) != ^uint64(0) {
+			t.Errorf("bad ctEq: %#x\n", x)
+		}
+		if (ctNonZero(x) == 1) != (x != 0) {
+			t.Errorf("bad ctNonZero: %#x\n", x)
+		}
+	}
+}
+
+func TestBase50Divmod50(t *testing.T) {
+	divmod50Ref := func(num uint64) (uint64, uint64) {
+		num >>= 4
+		return num / 50, num % 50
+	}
+	divmod50Tst := func(num uint64) (uint64, uint64) {
+		return divmod50(num >> 4)
+	}
+	if err := quick.CheckEqual(divmod50Tst, divmod50Ref, nil); err != nil {
+		t.Error(err)
+	}
+
+	for _, num := range []uint64{0, 49, 50, 2499, 2500, 0xFFFFFFFFFFFFFF,
+		97656249999999999, 1<<60 - 1} {
+		q, r := divmod50(num)
+		if q != num/50 || r != num%50 {
+			t.Errorf("bad divmod50: %d made %d %d\n", num, q, r)
+		}
+	}
+}
+
+func TestBase50EncodeSecret(t *testing.T) {
+	// A reversed alphabet, so the values aren't in ASCII order.
+	var rev []byte
+	for i := len(Alphabet) - 1; i >= 0; i-- {
+		rev = append(rev, Alphabet[i])
+	}
+	for _, enc := range []*Encoding{StdEncoding, mustNewEncoding(string(rev))} {
+		fenc := enc.FixedLength()
+		f := func(src []byte) bool {
+			encoded := enc.EncodeSecret(make([]byte, EncodeLen(len(src))), src)
+			if !bytes.Equal(encoded, fenc.EncodeToBytes(src)) {
+				return false
+			}
+
+			decoded, err := enc.DecodeSecret(make([]byte, len(src)), encoded)
+			return err == nil && bytes.Equal(decoded, src)
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Decoding in place, and without the stop character.
+	val := []byte("secret key material")
+	encoded := EncodeSecret(make([]byte, EncodeLen(len(val))), val)
+	decoded, err := DecodeSecret(encoded, encoded[:len(encoded)-1])
+	if err != nil || !bytes.Equal(decoded, val) {
+		t.Errorf("bad decode: %q %v\n", decoded, err)
+	}
+}
+
+func TestBase50DecodeSecretErrors(t *testing.T) {
+	data := []struct {
+		src string
+		err error
+	}{
+		{"", nil},
+		{"0", ErrNonCanonical},     // Shortened group
+		{"rwdnu", ErrNonCanonical}, // Shortened group
+		{"00.", nil},
+		{"0!.", ErrInvalidChar},
+		{"0 0.", ErrInvalidChar},    // No whitespace
+		{"zz.", ErrNonCanonical},    // More than 0xFF
+		{"zzzzzzzzzz", ErrOverflow}, // More than 0xFFFF_FFFF_FFFF_FF
+		{"zzzzzzzzzz!0", ErrInvalidChar},
+	}
+	for i := range data {
+		dst := make([]byte, DecodeLen(len(data[i].src)))
+		for j := range dst {
+			dst[j] = 0xAA
+		}
+
+		_, err := DecodeSecret(dst, []byte(data[i].src))
+		if err != data[i].err {
+			t.Errorf("bad err: <%s> made %v\n", data[i].src, err)
+		}
+		for j := range dst {
+			if err != nil && dst[j] != 0 && dst[j] != 0xAA {
+				t.Errorf("dst not zeroed: <%s> made %x\n", data[i].src, dst)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package base50
+
+import (
+	"bytes"
+	"io"
+)
+
+// WithStop returns a copy of enc where each encoded message always ends with
+// a stop character, even when the last group isn't shortened. Eg. "abcdefg"
+// is "H1jP5eefyh." and not "H1jP5eefyh". Without this a message that's a
+// multiple of 7 bytes runs into the next one when they are concatenated, so
+// use it to encode messages for DecodeSegments() and SegmentDecoder. An empty
+// message is encoded as a lone stop character, "." (and not as nothing).
+// WithCheck() messages already always end with a stop character.
+func (enc *Encoding) WithStop() *Encoding {
+	e := *enc
+	e.stop = true
+	return &e
+}
+
+// DecodeSegments decodes src, which is multiple encodings concatenated
+// together, returning the bytes of each message. A message ends at each stop
+// character, and at the end of src, so a stop character with nothing before
+// it is an empty message. If the input is malformed, it returns the messages
+// decoded before the error, and the error.
+//
+// The messages must be encoded WithStop() (or WithCheck()), as Encode() only
+// writes a stop character after a shortened last group, and a message that's
+// a multiple of 7 bytes would silently run into the next one.
+func DecodeSegments(src []byte) ([][]byte, error) {
+	return StdEncoding.DecodeSegments(src)
+}
+
+// DecodeSegments decodes the messages in src using the encoding enc, see
+// DecodeSegments().
+func (enc *Encoding) DecodeSegments(src []byte) ([][]byte, error) {
+	dst := make([]byte, enc.DecodeLen(len(src)))
+	g := groupDecoder{enc: enc, stop: true, segments: true}
+
+	count, err := g.decode(dst, src)
+	if err == nil {
+		_, err = g.end(dst[count:])
+	}
+	if err == nil && g.failed != nil {
+		err = g.failed
+	}
+
+	var ret [][]byte
+	start := 0
+	for _, end := range g.ends {
+		ret = append(ret, dst[start:end:end])
+		start = end
+	}
+	return ret, err
+}
+
+// ScanSegments is a bufio.SplitFunc which returns each decoded message of
+// the input, see DecodeSegments() and WithStop(). The message is decoded in
+// place, so it is only valid until the next call to Scan, and offsets in a
+// DecodeError are from the start of the message. The whole message needs to
+// fit into the buffer of the bufio.Scanner.
+func ScanSegments(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	return StdEncoding.ScanSegments(data, atEOF)
+}
+
+// ScanSegments is a bufio.SplitFunc using the encoding enc, see
+// ScanSegments().
+func (enc *Encoding) ScanSegments(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	i := bytes.IndexByte(data, '.')
+	switch {
+	case i >= 0:
+		i++
+		if trimEnd(data[:i]) == 0 { // An empty message
+			return i, data[:0], nil
+		}
+	case !atEOF:
+		return 0, nil, nil
+	default:
+		i = len(data)
+		if trimEnd(data) == 0 { // Only whitespace after the last message
+			return i, nil, nil
+		}
+	}
+
+	token, err = enc.Decode(data[:i], data[:i])
+	if err != nil {
+		return 0, nil, err
+	}
+	return i, token, nil
+}
+
+// A SegmentDecoder is a stream decoder for multiple encodings concatenated
+// together, see DecodeSegments() and WithStop(). Call Next() to get to each
+// message, and then Read() reads the message until io.EOF.
+type SegmentDecoder struct {
+	err     error
+	r       io.Reader
+	g       groupDecoder
+	buf     [1024]byte
+	out     []byte // leftover decoded output
+	outbuf  [1024 + 11]byte
+	pos     int  // number of decoded bytes read, or skipped
+	started bool // Next() has been called
+}
+
+// NewSegmentDecoder constructs a new base50 stream decoder for multiple
+// messages.
+func NewSegmentDecoder(r io.Reader) *SegmentDecoder {
+	return StdEncoding.NewSegmentDecoder(r)
+}
+
+// NewSegmentDecoder constructs a new base50 stream decoder for multiple
+// messages using the encoding enc, see NewSegmentDecoder().
+func (enc *Encoding) NewSegmentDecoder(r io.Reader) *SegmentDecoder {
+	return &SegmentDecoder{r: r,
+		g: groupDecoder{enc: enc, stop: true, segments: true}}
+}
+
+// fill reads and decodes more input, when there's no leftover output.
+func (s *SegmentDecoder) fill() {
+	nr, rerr := s.r.Read(s.buf[:])
+
+	var nd, nf int
+	nd, s.err = s.g.decode(s.outbuf[:], s.buf[:nr])
+	if s.err == nil && rerr == io.EOF {
+		nf, s.err = s.g.end(s.outbuf[nd:])
+	}
+	s.out = s.outbuf[:nd+nf]
+	if s.err != nil {
+		s.out = s.out[:s.g.verified(len(s.out))]
+	}
+	if s.err == nil && rerr == io.EOF && s.g.failed != nil {
+		s.err = s.g.failed
+	}
+	if s.err == nil {
+		s.err = rerr
+	}
+}
+
+// Next goes to the start of the next message, skipping any of the current
+// message that hasn't been read. It returns io.EOF when there are no more
+// messages.
+func (s *SegmentDecoder) Next() error {
+	if s.started {
+		for len(s.g.ends) == 0 || s.g.ends[0] != s.pos {
+			if len(s.out) == 0 {
+				if s.err != nil {
+					return s.err
+				}
+				s.fill()
+			}
+			n := s.readable()
+			s.out = s.out[n:]
+			s.pos += n
+		}
+		s.g.ends = s.g.ends[1:]
+	}
+	s.started = true
+
+	for len(s.g.ends) == 0 && len(s.out) == 0 {
+		if s.err != nil {
+			return s.err
+		}
+		s.fill()
+	}
+	return nil
+}
+
+// readable returns how much of the leftover output is in the current message.
+func (s *SegmentDecoder) readable() int {
+	n := len(s.out)
+	if len(s.g.ends) > 0 && s.g.ends[0]-s.pos < n {
+		n = s.g.ends[0] - s.pos
+	}
+	return n
+}
+
+// Read reads the decoded bytes of the current message, returning io.EOF at
+// the end of the message.
+func (s *SegmentDecoder) Read(p []byte) (int, error) {
+	if !s.started {
+		if err := s.Next(); err != nil {
+			return 0, err
+		}
+	}
+
+	for len(s.out) == 0 {
+		if len(s.g.ends) > 0 && s.g.ends[0] == s.pos {
+			return 0, io.EOF
+		}
+		if s.err != nil {
+			return 0, s.err
+		}
+		s.fill()
+	}
+
+	n := copy(p, s.out[:s.readable()])
+	s.out = s.out[n:]
+	s.pos += n
+	if n == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,226 @@
+package base50
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+// segmentsTestData returns messages of different lengths, including empty
+// ones, and their encodings concatenated together.
+func segmentsTestData(enc *Encoding) ([][]byte, []byte) {
+	val := []byte("abcdefghijklmnopqrstuvwxyz")
+	var msgs [][]byte
+	var encoded []byte
+	for _, l := range []int{0, 1, 7, 0, 3, 14, 26, 6} {
+		msgs = append(msgs, val[:l])
+		encoded = append(encoded, enc.WithStop().EncodeToBytes(val[:l])...)
+		encoded = append(encoded, '\n')
+	}
+	return msgs, encoded
+}
+
+func TestBase50DecodeSegments(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithCheck(),
+		StdEncoding.WithGroupCheck()} {
+		msgs, encoded := segmentsTestData(enc)
+
+		segs, err := enc.DecodeSegments(encoded)
+		if err != nil || len(segs) != len(msgs) {
+			t.Fatalf("bad decode: <%s> made %d %v\n", encoded, len(segs), err)
+		}
+		for i := range msgs {
+			if !bytes.Equal(segs[i], msgs[i]) {
+				t.Errorf("data not equal: %d\n tst=<%s>\n got <%s>\n",
+					i, msgs[i], segs[i])
+			}
+		}
+
+		// The last message doesn't need a stop character.
+		segs, err = enc.DecodeSegments(bytes.TrimRight(encoded, ".\n"))
+		if err != nil || len(segs) != len(msgs) ||
+			!bytes.Equal(segs[len(segs)-1], msgs[len(msgs)-1]) {
+			t.Errorf("bad decode: made %q %v\n", segs, err)
+		}
+
+		sc := bufio.NewScanner(iotest.OneByteReader(bytes.NewReader(encoded)))
+		sc.Split(enc.ScanSegments)
+		i := 0
+		for ; sc.Scan(); i++ {
+			if i >= len(msgs) || !bytes.Equal(sc.Bytes(), msgs[i]) {
+				t.Errorf("scan not equal: %d: <%s>\n", i, sc.Bytes())
+			}
+		}
+		if sc.Err() != nil || i != len(msgs) {
+			t.Errorf("bad scan: %d %v\n", i, sc.Err())
+		}
+	}
+
+	segs, err := DecodeSegments([]byte("1x.11.H1jP5eefyh.1O."))
+	if len(segs) != 3 || string(segs[2]) != "abcdefg" {
+		t.Errorf("bad decode: made %q\n", segs)
+	}
+	var derr *DecodeError
+	if !errors.As(err, &derr) || !errors.Is(err, ErrInvalidChar) ||
+		derr.Offset != 18 || derr.Group != 3 {
+		t.Errorf("bad err: %v\n", err)
+	}
+
+	// A stop character with nothing before it is an empty message.
+	segs, err = DecodeSegments([]byte(".1x. ..\n"))
+	if err != nil || len(segs) != 4 || string(bytes.Join(segs, []byte("|"))) != "|a||" {
+		t.Errorf("bad decode: made %q %v\n", segs, err)
+	}
+	sc := bufio.NewScanner(bytes.NewReader([]byte("1x. ..1O.")))
+	sc.Split(ScanSegments)
+	var scanned []string
+	for sc.Scan() {
+		scanned = append(scanned, sc.Text())
+	}
+	if len(scanned) != 3 || scanned[0] != "a" || scanned[1] != "" ||
+		scanned[2] != "" || !errors.Is(sc.Err(), ErrInvalidChar) {
+		t.Errorf("bad scan: %q %v\n", scanned, sc.Err())
+	}
+}
+
+func TestBase50WithStop(t *testing.T) {
+	recs := []string{"1234567", "abc", "ABCDEFGHIJKLMN", "z"}
+
+	// Without a stop character "1234567" runs into "abc".
+	var encoded []byte
+	for _, rec := range recs {
+		encoded = append(encoded, EncodeToBytes([]byte(rec))...)
+	}
+	if segs, _ := DecodeSegments(encoded); len(segs) == len(recs) {
+		t.Errorf("bad decode: made %q\n", segs)
+	}
+
+	val := []byte("abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz")
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithGroupCheck(),
+		StdEncoding.Ordered(), StdEncoding.Dense(), StdEncoding.FixedLength(),
+		StdEncoding.Strict(), StdEncoding.WithCheck()} {
+		senc := enc.WithStop()
+		gb := senc.groupBytes()
+
+		var lens []int
+		for _, l := range []int{1, gb, 0, 3, 2 * gb, gb + 1, gb - 1} {
+			if l <= len(val) {
+				lens = append(lens, l)
+			}
+		}
+		encoded, streamed := []byte(nil), bytes.Buffer{}
+		for _, l := range lens {
+			out := senc.EncodeToBytes(val[:l])
+			if len(out) != senc.EncodeLen(l) || out[len(out)-1] != '.' {
+				t.Errorf("bad encode: %d <%s>\n", l, out)
+			}
+			if err := senc.Valid(out); err != nil {
+				t.Errorf("bad valid: %d <%s> %v\n", l, out, err)
+			}
+			encoded = append(encoded, out...)
+
+			w := senc.NewEncoder(&streamed)
+			w.Write(val[:l])
+			w.Close()
+			w.Close()
+		}
+		if !bytes.Equal(streamed.Bytes(), encoded) {
+			t.Errorf("stream not equal:\n tst=<%s>\n got <%s>\n",
+				encoded, streamed.Bytes())
+		}
+
+		if enc.strict {
+			senc = StdEncoding.WithStop()
+		}
+		segs, err := senc.DecodeSegments(encoded)
+		if err != nil || len(segs) != len(lens) {
+			t.Fatalf("bad decode: <%s> made %q %v\n", encoded, segs, err)
+		}
+		for i, l := range lens {
+			if !bytes.Equal(segs[i], val[:l]) {
+				t.Errorf("data not equal: %d <%s>\n", i, segs[i])
+			}
+		}
+	}
+
+	// Strict() decoding needs the stop character after a full group.
+	senc := StdEncoding.WithStop().Strict()
+	if _, err := senc.DecodeString("H1jP5eefyh"); !errors.Is(err, ErrStopChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := senc.DecodeString("H1jP5eefyh.."); !errors.Is(err, ErrStopChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := StdEncoding.Strict().DecodeString("H1jP5eefyh."); !errors.Is(err, ErrStopChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+	if _, err := senc.DecodeString(""); !errors.Is(err, ErrStopChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+
+	// An empty message is a lone stop character, but only one of them.
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithCheck(),
+		StdEncoding.Strict()} {
+		senc := enc.WithStop()
+		if out := senc.EncodeToString(nil); out != "." {
+			t.Errorf("bad encode: <%s>\n", out)
+		}
+		if decoded, err := senc.DecodeString("."); err != nil || len(decoded) != 0 {
+			t.Errorf("bad decode: <%s> %v\n", decoded, err)
+		}
+		if _, err := senc.DecodeString(".."); !errors.Is(err, ErrTruncatedGroup) &&
+			!errors.Is(err, ErrStopChar) {
+			t.Errorf("bad err: %v\n", err)
+		}
+	}
+	if _, err := StdEncoding.DecodeString(".."); !errors.Is(err, ErrTruncatedGroup) {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
+
+func TestBase50SegmentDecoder(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithCheck(),
+		StdEncoding.WithGroupCheck()} {
+		msgs, encoded := segmentsTestData(enc)
+
+		for _, skip := range []bool{false, true} {
+			r := iotest.OneByteReader(bytes.NewReader(encoded))
+			sd := enc.NewSegmentDecoder(r)
+			i := 0
+			for ; ; i++ {
+				if err := sd.Next(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("bad err: %d: %v\n", i, err)
+				}
+				if skip && i%2 == 0 {
+					continue
+				}
+
+				decoded, err := ioutil.ReadAll(sd)
+				if err != nil || i >= len(msgs) || !bytes.Equal(decoded, msgs[i]) {
+					t.Errorf("data not equal: %d: <%s> %v\n", i, decoded, err)
+				}
+			}
+			if i != len(msgs) {
+				t.Errorf("bad number of messages: %d\n", i)
+			}
+		}
+	}
+
+	sd := NewSegmentDecoder(bytes.NewReader([]byte("1x.1O.")))
+	if decoded, err := ioutil.ReadAll(sd); err != nil || string(decoded) != "a" {
+		t.Errorf("bad read: %q %v\n", decoded, err)
+	}
+	if err := sd.Next(); !errors.Is(err, ErrInvalidChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+
+	if err := NewSegmentDecoder(bytes.NewReader([]byte(" \n"))).Next(); err != io.EOF {
+		t.Errorf("bad err: %v\n", err)
+	}
+}
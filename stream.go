@@ -0,0 +1,221 @@
+package base50
+
+import (
+	"context"
+	"io"
+)
+
+type encoder struct {
+	err  error
+	enc  *Encoding
+	w    io.Writer
+	buf  [31]byte   // buffered data waiting to be encoded
+	nbuf int        // number of bytes in buf
+	out  [1122]byte // 102 groups, with group checks
+	luhn luhn       // check for WithCheck() encodings
+	nout int        // number of bytes written
+	pos  int64      // number of characters written, for WithLineWrap()
+	done bool       // Close() has been called
+}
+
+// write encodes src, which must be whole groups unless it's the end, and
+// writes it out.
+func (e *encoder) write(src []byte) error {
+	n, err := e.enc.encodeGroups(e.out[:], src)
+	if err != nil {
+		return err
+	}
+	out := e.out[:n]
+	if e.enc.check {
+		if len(out) > 0 && out[len(out)-1] == '.' {
+			out = out[:len(out)-1]
+		}
+		e.luhn.addChars(e.enc, out)
+	}
+	e.nout += len(out)
+
+	return e.writeOut(len(out))
+}
+
+// writeOut writes out the first n encoded characters in e.out, adding any
+// newlines and separators.
+func (e *encoder) writeOut(n int) error {
+	m := e.enc.format(e.out[:], n, e.pos)
+	e.pos += int64(n)
+	_, err := e.w.Write(e.out[:m])
+	return err
+}
+
+func (e *encoder) Write(p []byte) (n int, err error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	gb := e.enc.groupBytes()
+
+	// Leading fringe.
+	if e.nbuf > 0 {
+		var i int
+		for i = 0; i < len(p) && e.nbuf < gb; i++ {
+			e.buf[e.nbuf] = p[i]
+			e.nbuf++
+		}
+		n += i
+		p = p[i:]
+		if e.nbuf < gb {
+			return n, nil
+		}
+		if e.err = e.write(e.buf[:gb]); e.err != nil {
+			return n, e.err
+		}
+		e.nbuf = 0
+	}
+
+	// Large interior chunks.
+	size := len(e.out)
+	if e.enc.formatted() { // Leave room for upto a separator each
+		size /= 2
+	}
+	for len(p) >= gb {
+		nn := size / e.enc.groupLen() * gb
+		if nn > len(p) {
+			nn = len(p)
+			nn -= nn % gb
+		}
+		if e.err = e.write(p[:nn]); e.err != nil {
+			return n, e.err
+		}
+		n += nn
+		p = p[nn:]
+	}
+
+	// Trailing fringe.
+	copy(e.buf[:], p)
+	e.nbuf = len(p)
+	n += len(p)
+	return n, nil
+}
+
+// Close flushes any pending output from the encoder, this is the shortened
+// final group and the stop character (always, for WithStop() encodings). It
+// is an error to call Write after calling Close.
+func (e *encoder) Close() error {
+	if e.done {
+		return e.err
+	}
+	e.done = true
+
+	// If there's anything left in the buffer, flush it out
+	whole := e.nbuf == 0
+	if e.err == nil && e.nbuf > 0 {
+		e.err = e.write(e.buf[:e.nbuf])
+		e.nbuf = 0
+	}
+	if e.err == nil && e.nout == 0 && e.enc.stop { // An empty message
+		e.out[0] = '.'
+		e.err = e.writeOut(1)
+		return e.err
+	}
+	if e.err != nil || e.nout == 0 {
+		return e.err
+	}
+
+	switch {
+	case e.enc.check:
+		n := e.enc.appendCheck(e.out[:], 0, &e.luhn)
+		e.err = e.writeOut(n)
+	case e.enc.stop && whole: // The last group didn't have a stop character
+		e.out[0] = '.'
+		e.err = e.writeOut(1)
+	}
+	e.nout = 0
+	return e.err
+}
+
+// NewEncoder returns a new base50 stream encoder. Data written to the returned
+// writer will be encoded and then written to w. Base50 encodings operate in
+// 7-byte groups; when finished writing, the caller must Close the returned
+// encoder to flush any partially written group (and the stop character).
+func NewEncoder(w io.Writer) io.WriteCloser {
+	return StdEncoding.NewEncoder(w)
+}
+
+// NewEncoder returns a new base50 stream encoder using the encoding enc, see
+// NewEncoder().
+func (enc *Encoding) NewEncoder(w io.Writer) io.WriteCloser {
+	return &encoder{enc: enc, w: w}
+}
+
+type decoder struct {
+	err    error
+	ctx    context.Context
+	r      io.Reader
+	g      groupDecoder
+	buf    [1024]byte
+	out    []byte // leftover decoded output
+	outbuf [1024 + 11]byte
+}
+
+func (d *decoder) Read(p []byte) (n int, err error) {
+	// Use leftover decoded output from last read.
+	if len(d.out) > 0 {
+		n = copy(p, d.out)
+		d.out = d.out[n:]
+		return n, nil
+	}
+
+	for len(d.out) == 0 && d.err == nil {
+		if d.err = d.ctx.Err(); d.err != nil {
+			break
+		}
+		nr, rerr := d.r.Read(d.buf[:])
+
+		var nd, nf int
+		nd, d.err = d.g.decode(d.outbuf[:], d.buf[:nr])
+		if d.err == nil && rerr == io.EOF {
+			nf, d.err = d.g.end(d.outbuf[nd:])
+		}
+		d.out = d.outbuf[:nd+nf]
+		if d.err != nil {
+			d.out = d.out[:d.g.verified(len(d.out))]
+		}
+		if d.err == nil && rerr == io.EOF && d.g.failed != nil {
+			d.err = d.g.failed
+		}
+		if d.err == nil {
+			d.err = rerr
+		}
+	}
+
+	n = copy(p, d.out)
+	d.out = d.out[n:]
+	if len(d.out) > 0 {
+		return n, nil
+	}
+	return n, d.err
+}
+
+// NewDecoder constructs a new base50 stream decoder. Like Decode() whitespace,
+// underbars and stop characters are handled, even when split across reads.
+func NewDecoder(r io.Reader) io.Reader {
+	return StdEncoding.NewDecoder(r)
+}
+
+// NewDecoder constructs a new base50 stream decoder using the encoding enc,
+// see NewDecoder().
+func (enc *Encoding) NewDecoder(r io.Reader) io.Reader {
+	return enc.NewDecoderContext(context.Background(), r)
+}
+
+// NewDecoderContext constructs a new base50 stream decoder, like NewDecoder(),
+// which stops with the error from ctx when ctx is done. This is checked
+// before each read from r, so a read which blocks isn't stopped.
+func NewDecoderContext(ctx context.Context, r io.Reader) io.Reader {
+	return StdEncoding.NewDecoderContext(ctx, r)
+}
+
+// NewDecoderContext constructs a new base50 stream decoder using the encoding
+// enc, see NewDecoderContext().
+func (enc *Encoding) NewDecoderContext(ctx context.Context, r io.Reader) io.Reader {
+	return &decoder{ctx: ctx, r: r, g: groupDecoder{enc: enc, stop: true}}
+}
@@ -0,0 +1,131 @@
+package base50
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBase50StreamEncoder(t *testing.T) {
+	data := []byte("abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	for l := 0; l <= len(data); l++ {
+		for chunk := 1; chunk <= 9; chunk++ {
+			var bb bytes.Buffer
+			enc := NewEncoder(&bb)
+			src := data[:l]
+			for len(src) > 0 {
+				n := chunk
+				if n > len(src) {
+					n = len(src)
+				}
+				if _, err := enc.Write(src[:n]); err != nil {
+					t.Fatalf("write err: %v\n", err)
+				}
+				src = src[n:]
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatalf("close err: %v\n", err)
+			}
+
+			tst := EncodeToString(data[:l])
+			if bb.String() != tst {
+				t.Errorf("data not equal: len=%d chunk=%d\n tst=<%s>\n got <%s>\n",
+					l, chunk, tst, bb.String())
+			}
+		}
+	}
+}
+
+func TestBase50StreamEncoderBig(t *testing.T) {
+	data := bytes.Repeat([]byte{0xFF, 0x00, 0x7F}, 4000)
+
+	var bb bytes.Buffer
+	enc := NewEncoder(&bb)
+	if _, err := enc.Write(data); err != nil {
+		t.Fatalf("write err: %v\n", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("close err: %v\n", err)
+	}
+
+	if bb.String() != EncodeToString(data) {
+		t.Errorf("data not equal: len=%d\n", len(data))
+	}
+}
+
+func TestBase50StreamDecoder(t *testing.T) {
+	data := []string{
+		"",
+		"1x.",
+		"H1jP5eefyh",
+		"H1jP5eefyh1x.",
+		"1x.1x.",
+		"1x.9yb.112sa.",
+		" H1jP5\teefyh\n 112_sa.\r\n",
+		"0000000000000000000.",
+		"H1jP5eefyhH1jP5eefyhH1jP5eefyh2k2peLhHS",
+	}
+
+	for i := range data {
+		tst, err := DecodeString(data[i])
+		if err != nil {
+			t.Fatalf("bad err: %d: %v\n", i, err)
+		}
+
+		readers := []io.Reader{
+			strings.NewReader(data[i]),
+			iotest.OneByteReader(strings.NewReader(data[i])),
+			iotest.DataErrReader(strings.NewReader(data[i])),
+			iotest.HalfReader(strings.NewReader(data[i])),
+		}
+		for j, r := range readers {
+			got, err := ioutil.ReadAll(NewDecoder(r))
+			if err != nil {
+				t.Errorf("bad err: %d/%d: %v\n", i, j, err)
+			}
+			if !bytes.Equal(got, tst) {
+				t.Errorf("data not equal: %d/%d: %v\n tst=<%x>\n got <%x>\n",
+					i, j, data[i], tst, got)
+			}
+		}
+	}
+}
+
+func TestBase50StreamDecoderBig(t *testing.T) {
+	data := bytes.Repeat([]byte{0xFF, 0x00, 0x7F, 0x01, 0x02}, 4000)
+
+	encoded := EncodeToString(data)
+	got, err := ioutil.ReadAll(NewDecoder(strings.NewReader(encoded)))
+	if err != nil {
+		t.Fatalf("bad err: %v\n", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("data not equal: len=%d got len=%d\n", len(data), len(got))
+	}
+}
+
+func TestBase50StreamDecoderErr(t *testing.T) {
+	data := []struct {
+		val string
+		tst []byte
+	}{
+		{"1x.!", []byte{'a'}},
+		{"H1jP5eefyh 56.", []byte("abcdefg")},
+		{"zzzzzzzzzz", nil},
+	}
+
+	for i := range data {
+		r := iotest.OneByteReader(strings.NewReader(data[i].val))
+		got, err := ioutil.ReadAll(NewDecoder(r))
+		if err == nil {
+			t.Errorf("no err: %d: %v\n", i, data[i].val)
+		}
+		if !bytes.Equal(got, data[i].tst) {
+			t.Errorf("data not equal: %d: %v\n tst=<%x>\n got <%x>\n",
+				i, data[i].val, data[i].tst, got)
+		}
+	}
+}
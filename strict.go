@@ -0,0 +1,62 @@
+package base50
+
+import (
+	"errors"
+)
+
+// ErrStopChar is for Strict() decoding where the stop character is missing
+// after a shortened group, or is somewhere Encode() wouldn't put it.
+var ErrStopChar = errors.New("base50: missing or misplaced stop character")
+
+// Strict returns a copy of enc which only decodes exactly what Encode()
+// outputs, so each message has a single encoding. Whitespace and underbars
+// are invalid, shortened groups must be as short as possible (Eg. "0x" should
+// be "x") and there must be a stop character after a shortened last group,
+// or the check character of WithCheck(), and nowhere else. So only a single
+// message can be decoded. For WithLineWrap() and WithSeparator() encodings
+// the newlines and separators must be exactly where Encode() puts them. This
+// can't be used with Lenient().
+func (enc *Encoding) Strict() *Encoding {
+	e := *enc
+	e.strict = true
+
+	for c, to := range e.subst {
+		if to != 0 {
+			e.decodeMap[c] = invalidChar
+			e.subst[c] = 0
+		}
+	}
+
+	return &e
+}
+
+// IsCanonical returns true if src is exactly what Encode() outputs for the
+// data it decodes to, see Strict().
+func IsCanonical(src []byte) bool {
+	return StdEncoding.IsCanonical(src)
+}
+
+// IsCanonical returns true if src is exactly what Encode() outputs using the
+// encoding enc, see IsCanonical().
+func (enc *Encoding) IsCanonical(src []byte) bool {
+	return enc.Strict().Valid(src) == nil
+}
+
+// Canonicalize decodes src and encodes it again, returning the single
+// canonical encoding for what src decodes to. Any concatenated encodings in
+// src are merged into one.
+func Canonicalize(src []byte) ([]byte, error) {
+	return StdEncoding.Canonicalize(src)
+}
+
+// Canonicalize returns the canonical encoding of src using the encoding enc,
+// see Canonicalize(). If enc is Strict() src is still decoded as normal.
+func (enc *Encoding) Canonicalize(src []byte) ([]byte, error) {
+	e := *enc
+	e.strict = false
+	decoded, err := e.Decode(make([]byte, e.DecodeLen(len(src))), src)
+	if err != nil {
+		return nil, err
+	}
+	return enc.EncodeToBytes(decoded), nil
+}
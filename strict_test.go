@@ -0,0 +1,95 @@
+package base50
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBase50Strict(t *testing.T) {
+	senc := StdEncoding.Strict()
+
+	data := []struct {
+		val string
+		tst string // canonical encoding, "" if val is canonical
+		err error
+	}{
+		{"", "", nil},
+		{"x.", "", nil},
+		{"0x.", "x.", ErrNonCanonical},
+		{"1x.", "", nil},
+		{"1x", "1x.", ErrStopChar},
+		{"1x.\n", "1x.", ErrStopChar},
+		{"1x\n.", "1x.", ErrInvalidChar},
+		{"H1jP5eefyh", "", nil},
+		{"H1jP5eefyh.", "H1jP5eefyh", ErrStopChar},
+		{"H1jP5_eefyh", "H1jP5eefyh", ErrInvalidChar},
+		{"H1jP5eefyh112sa.", "", nil},
+		{"H1jP5eefyh.112sa.", "H1jP5eefyh112sa.", ErrStopChar},
+		{"1x.11.", "9xf.", ErrStopChar},
+		{".", "", ErrStopChar},
+		{"H1jP5eefyh01FLU.", "H1jP5eefyh1FLU.", ErrNonCanonical},
+		{"0000000000", "", nil},
+		{"56.", "", ErrNonCanonical},
+	}
+
+	for i := range data {
+		_, err := senc.DecodeString(data[i].val)
+		if !errors.Is(err, data[i].err) || (err == nil) != (data[i].err == nil) {
+			t.Errorf("bad err: %d: %q made %v\n", i, data[i].val, err)
+		}
+		if IsCanonical([]byte(data[i].val)) != (err == nil) {
+			t.Errorf("bad canonical: %d: %q\n", i, data[i].val)
+		}
+
+		if _, err := DecodeString(data[i].val); err != nil {
+			continue // Doesn't decode at all
+		}
+		tst := data[i].tst
+		if tst == "" {
+			tst = data[i].val
+		}
+		canon, err := Canonicalize([]byte(data[i].val))
+		if err != nil || string(canon) != tst {
+			t.Errorf("data not equal: %d: %q\n tst=<%s>\n got <%s> %v\n",
+				i, data[i].val, tst, canon, err)
+		}
+	}
+
+	var derr *DecodeError
+	if _, err := senc.DecodeString("1x.2"); !errors.As(err, &derr) ||
+		derr.Offset != 3 || !errors.Is(err, ErrStopChar) {
+		t.Errorf("bad err: %v\n", err)
+	}
+
+	// Everything Encode() outputs is canonical.
+	val := []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0xFF}
+	for _, enc := range []*Encoding{StdEncoding, StdEncoding.WithCheck(),
+		StdEncoding.WithGroupCheck()} {
+		for l := 0; l <= len(val); l++ {
+			encoded := enc.EncodeToBytes(val[:l])
+			decoded, err := enc.Strict().Decode(make([]byte, l), encoded)
+			if err != nil || !bytes.Equal(decoded, val[:l]) {
+				t.Errorf("bad decode: %d: <%s> made %v\n", l, encoded, err)
+			}
+			if l > 0 && enc.check &&
+				enc.IsCanonical(encoded[:len(encoded)-1]) {
+				t.Errorf("no stop character is canonical: %d: <%s>\n",
+					l, encoded)
+			}
+		}
+	}
+
+	// Look-alike characters aren't canonical.
+	lenc := StdEncoding.Lenient()
+	if lenc.IsCanonical([]byte("1O.")) || !lenc.IsCanonical([]byte("10.")) {
+		t.Errorf("look-alike characters are canonical\n")
+	}
+	if canon, err := lenc.Canonicalize([]byte("1O.")); err != nil ||
+		string(canon) != "10." {
+		t.Errorf("bad canonical: <%s> %v\n", canon, err)
+	}
+	if !lenc.Strict().Lenient().Strict().strict || lenc.Strict().Lenient().strict {
+		t.Errorf("Strict() and Lenient() mixed\n")
+	}
+}